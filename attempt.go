@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Attempt is information about the current attempt, it is available from the operation context, see AttemptFromContext.
+type Attempt struct {
+	// Number of the attempt starting from 1.
+	Number int
+	// Elapsed is the time elapsed since the retrying started.
+	Elapsed time.Duration
+	// PrevErr is the error of the previous attempt, nil for the first attempt.
+	PrevErr error
+
+	more func() bool
+}
+
+// More reports whether the strategy allows another attempt if this one fails.
+// The strategy delay is taken in advance at the first call, so for time based strategies the answer is
+// given at the moment of the call. Other limits, such as a retry budget or a circuit breaker, may still stop retrying.
+func (a Attempt) More() bool {
+	if a.more == nil {
+		return false
+	}
+	return a.more()
+}
+
+type attemptKey struct{}
+
+// AttemptFromContext returns the current attempt from the operation context.
+func AttemptFromContext(ctx context.Context) (Attempt, bool) {
+	a, ok := ctx.Value(attemptKey{}).(Attempt)
+	return a, ok
+}
+
+func withAttempt(ctx context.Context, a Attempt) context.Context {
+	return context.WithValue(ctx, attemptKey{}, a)
+}
+
+// peekIterator allows to take the next delay in advance.
+type peekIterator struct {
+	mu     sync.Mutex
+	next   Iterator
+	peeked bool
+	delay  time.Duration
+	err    error
+}
+
+func (p *peekIterator) peek() (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.peeked {
+		p.delay, p.err = p.next()
+		p.peeked = true
+	}
+	return p.delay, p.err
+}
+
+func (p *peekIterator) take() (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.peeked {
+		p.peeked = false
+		return p.delay, p.err
+	}
+	return p.next()
+}
+
+func (p *peekIterator) more() bool {
+	d, _ := p.peek()
+	return d != StopDelay
+}
@@ -0,0 +1,68 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAttemptFromContext(t *testing.T) {
+	t.Parallel()
+
+	if _, ok := AttemptFromContext(context.Background()); ok {
+		t.Error("unexpected attempt in the background context")
+	}
+
+	errs := []error{errors.New("first"), errors.New("second")}
+	var attempts []Attempt
+	var more []bool
+	err := Do(context.Background(), Constant(time.Second), func(ctx context.Context) error {
+		a, ok := AttemptFromContext(ctx)
+		if !ok {
+			return Permanent(errors.New("expected attempt in the context"))
+		}
+		attempts = append(attempts, a)
+		more = append(more, a.More())
+		return errs[len(attempts)-1]
+	}, WithClock(NewAutoFakeClock(time.Now())), WithMaxRetries(1))
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if len(attempts) != 2 {
+		t.Fatalf("attempts want: %d, got: %d", 2, len(attempts))
+	}
+	if a := attempts[0]; a.Number != 1 || a.Elapsed != 0 || a.PrevErr != nil {
+		t.Errorf("unexpected first attempt: %+v", a)
+	}
+	if a := attempts[1]; a.Number != 2 || a.Elapsed != time.Second || a.PrevErr != errs[0] {
+		t.Errorf("unexpected second attempt: %+v", a)
+	}
+	if !more[0] || more[1] {
+		t.Errorf("more want: %v, got: %v", []bool{true, false}, more)
+	}
+}
+
+func TestAttempt_MoreKeepsDelays(t *testing.T) {
+	t.Parallel()
+
+	var delays []time.Duration
+	count := 0
+	_ = Do(context.Background(), Delays{time.Second, 2 * time.Second}, func(ctx context.Context) error {
+		count++
+		if count == 1 {
+			a, _ := AttemptFromContext(ctx)
+			// Asking twice doesn't take two delays.
+			a.More()
+			a.More()
+		}
+		return errors.New("error")
+	}, WithClock(NewAutoFakeClock(time.Now())), WithNotify(func(err error, delay time.Duration, try int, elapsed time.Duration) {
+		delays = append(delays, delay)
+	}))
+
+	if len(delays) != 2 || delays[0] != time.Second || delays[1] != 2*time.Second {
+		t.Errorf("delays want: %v, got: %v", []time.Duration{time.Second, 2 * time.Second}, delays)
+	}
+}
@@ -0,0 +1,223 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is a reason of stopping when the circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is a state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed allows all calls.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects all calls until the cool-down passes.
+	BreakerOpen
+	// BreakerHalfOpen allows a limited count of probe calls.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreaker stops calling an operation when it keeps failing.
+//
+// The breaker opens when ConsecutiveFailures failures happen in a row,
+// or when the rate of failures among the last Window calls reaches FailureRate.
+// After CoolDown the breaker becomes half-open and allows HalfOpenProbes calls,
+// a successful probe closes the breaker and a failed one opens it again.
+//
+// The breaker is safe for concurrent use and must not be copied after first use.
+type CircuitBreaker struct {
+	// ConsecutiveFailures is the count of consecutive failures that opens the breaker, zero disables the threshold.
+	ConsecutiveFailures int
+	// FailureRate is the rate of failures in the range (0, 1] that opens the breaker, zero disables the threshold.
+	FailureRate float64
+	// Window is the count of the last calls the failure rate is calculated over.
+	Window int
+	// CoolDown is the time the breaker stays open.
+	CoolDown time.Duration
+	// HalfOpenProbes is the count of calls allowed in the half-open state, 1 by default.
+	HalfOpenProbes int
+	// IsFailure reports whether an operation error counts as a failure when the breaker is used by DoR.
+	// By default all errors count except permanent errors and errors stopped by the classification.
+	// Errors of canceled attempts never count.
+	IsFailure Predicate
+	// OnStateChange is called when the state of the breaker changes.
+	OnStateChange func(from, to BreakerState)
+	// Clock is a time source, SystemClock by default.
+	Clock Clock
+
+	mu        sync.Mutex
+	state     BreakerState
+	changedAt time.Time
+	failures  int
+	results   []bool
+	pos       int
+	probes    int
+}
+
+// NewCircuitBreaker creates the circuit breaker that opens after n consecutive failures for the cool-down time.
+func NewCircuitBreaker(n int, coolDown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{ConsecutiveFailures: n, CoolDown: coolDown}
+}
+
+// State returns the current state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow checks whether a call is allowed. It returns ErrCircuitOpen if it's not.
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	from := b.state
+	now := clockOrDefault(b.Clock).Now()
+	allowed := true
+	switch b.state {
+	case BreakerOpen:
+		if now.Sub(b.changedAt) < b.CoolDown {
+			allowed = false
+			break
+		}
+		b.setState(BreakerHalfOpen, now)
+		b.probes = 1
+	case BreakerHalfOpen:
+		// Probes that never reported the result must not block the breaker forever.
+		if now.Sub(b.changedAt) >= b.CoolDown {
+			b.changedAt = now
+			b.probes = 0
+		}
+		if b.probes >= b.halfOpenProbes() {
+			allowed = false
+			break
+		}
+		b.probes++
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	if !allowed {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// Success reports a successful call.
+func (b *CircuitBreaker) Success() {
+	b.report(true)
+}
+
+// Failure reports a failed call.
+func (b *CircuitBreaker) Failure() {
+	b.report(false)
+}
+
+// Ignore reports a call whose result doesn't count, for example a canceled one.
+// It releases the half-open probe taken by the call.
+func (b *CircuitBreaker) Ignore() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+}
+
+func (b *CircuitBreaker) report(success bool) {
+	b.mu.Lock()
+	from := b.state
+	now := clockOrDefault(b.Clock).Now()
+	switch b.state {
+	case BreakerHalfOpen:
+		if success {
+			b.reset()
+			b.setState(BreakerClosed, now)
+		} else {
+			b.setState(BreakerOpen, now)
+		}
+	case BreakerClosed:
+		b.record(success)
+		if b.tripped() {
+			b.reset()
+			b.setState(BreakerOpen, now)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+func (b *CircuitBreaker) record(success bool) {
+	if success {
+		b.failures = 0
+	} else {
+		b.failures++
+	}
+	if b.Window <= 0 {
+		return
+	}
+	if len(b.results) < b.Window {
+		b.results = append(b.results, success)
+		return
+	}
+	b.results[b.pos] = success
+	b.pos = (b.pos + 1) % b.Window
+}
+
+func (b *CircuitBreaker) tripped() bool {
+	if b.ConsecutiveFailures > 0 && b.failures >= b.ConsecutiveFailures {
+		return true
+	}
+	if b.FailureRate <= 0 || b.Window <= 0 || len(b.results) < b.Window {
+		return false
+	}
+	failures := 0
+	for _, success := range b.results {
+		if !success {
+			failures++
+		}
+	}
+	return float64(failures)/float64(len(b.results)) >= b.FailureRate
+}
+
+func (b *CircuitBreaker) reset() {
+	b.failures = 0
+	b.results = b.results[:0]
+	b.pos = 0
+	b.probes = 0
+}
+
+func (b *CircuitBreaker) setState(state BreakerState, now time.Time) {
+	b.state = state
+	b.changedAt = now
+}
+
+func (b *CircuitBreaker) halfOpenProbes() int {
+	if b.HalfOpenProbes <= 0 {
+		return 1
+	}
+	return b.HalfOpenProbes
+}
+
+func (b *CircuitBreaker) notify(from, to BreakerState) {
+	if from != to && b.OnStateChange != nil {
+		b.OnStateChange(from, to)
+	}
+}
@@ -0,0 +1,169 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_ConsecutiveFailures(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	var changes []BreakerState
+	b := NewCircuitBreaker(3, time.Minute)
+	b.Clock = clock
+	b.OnStateChange = func(from, to BreakerState) {
+		changes = append(changes, to)
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := b.Allow(); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		b.Failure()
+	}
+	if b.State() != BreakerOpen {
+		t.Fatalf("state want: %s, got: %s", BreakerOpen, b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("want: %s, got: %v", ErrCircuitOpen, err)
+	}
+
+	clock.Advance(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if b.State() != BreakerHalfOpen {
+		t.Fatalf("state want: %s, got: %s", BreakerHalfOpen, b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("only one probe expected, got: %v", err)
+	}
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Fatalf("state want: %s, got: %s", BreakerOpen, b.State())
+	}
+
+	clock.Advance(time.Minute)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	b.Success()
+	if b.State() != BreakerClosed {
+		t.Fatalf("state want: %s, got: %s", BreakerClosed, b.State())
+	}
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(changes) != len(want) {
+		t.Fatalf("state changes want: %v, got: %v", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("state change %d want: %s, got: %s", i, want[i], changes[i])
+		}
+	}
+}
+
+func TestCircuitBreaker_FailureRate(t *testing.T) {
+	t.Parallel()
+
+	b := &CircuitBreaker{FailureRate: 0.5, Window: 4, CoolDown: time.Minute}
+	b.Success()
+	b.Failure()
+	b.Success()
+	if b.State() != BreakerClosed {
+		t.Fatalf("state want: %s, got: %s", BreakerClosed, b.State())
+	}
+	b.Failure()
+	if b.State() != BreakerOpen {
+		t.Fatalf("state want: %s, got: %s", BreakerOpen, b.State())
+	}
+}
+
+func TestDo_Breaker(t *testing.T) {
+	t.Parallel()
+
+	b := NewCircuitBreaker(2, time.Hour)
+	count := 0
+	operation := func(ctx context.Context) error {
+		count++
+		return errors.New("error")
+	}
+
+	err := Do(context.Background(), Zero(), operation, WithBreaker(b))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("want: %s, got: %v", ErrCircuitOpen, err)
+	}
+	if count != 2 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 2)
+	}
+	if e := As(err); e == nil || e.Err == nil || e.Reason != ErrCircuitOpen {
+		t.Errorf("unexpected error: %#v", err)
+	}
+
+	// Fails fast without calling the operation.
+	err = Do(context.Background(), Zero(), operation, WithBreaker(b))
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("want: %s, got: %v", ErrCircuitOpen, err)
+	}
+	if count != 2 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 2)
+	}
+}
+
+func TestDo_BreakerIgnored(t *testing.T) {
+	t.Parallel()
+
+	errBadRequest := errors.New("bad request")
+	b := NewCircuitBreaker(1, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	_ = Do(ctx, Zero(), func(ctx context.Context) error {
+		cancel()
+		return ctx.Err()
+	}, WithBreaker(b))
+	_ = Do(context.Background(), Zero(), func(ctx context.Context) error {
+		return Permanent(errBadRequest)
+	}, WithBreaker(b))
+	_ = Do(context.Background(), Zero(), func(ctx context.Context) error {
+		return errBadRequest
+	}, WithBreaker(b), WithStopIf(StopOn(errBadRequest)))
+	if b.State() != BreakerClosed {
+		t.Fatalf("state want: %s, got: %s", BreakerClosed, b.State())
+	}
+
+	b.IsFailure = func(err error) bool { return errors.Is(err, errBadRequest) }
+	_ = Do(context.Background(), Zero(), func(ctx context.Context) error {
+		return Permanent(errBadRequest)
+	}, WithBreaker(b))
+	if b.State() != BreakerOpen {
+		t.Fatalf("state want: %s, got: %s", BreakerOpen, b.State())
+	}
+}
+
+func TestCircuitBreaker_Ignore(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	b := NewCircuitBreaker(1, time.Minute)
+	b.Clock = clock
+	_ = b.Allow()
+	b.Failure()
+	clock.Advance(time.Minute)
+
+	if err := b.Allow(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("want: %s, got: %v", ErrCircuitOpen, err)
+	}
+	b.Ignore()
+	if err := b.Allow(); err != nil {
+		t.Errorf("the ignored probe must be released, got: %v", err)
+	}
+	if b.State() != BreakerHalfOpen {
+		t.Errorf("state want: %s, got: %s", BreakerHalfOpen, b.State())
+	}
+}
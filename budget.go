@@ -0,0 +1,90 @@
+package retry
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExhausted is a reason of stopping when the retry budget is exhausted.
+var ErrBudgetExhausted = errors.New("retry budget exhausted")
+
+// defaultMaxTokens is the default maximum of retries accumulated by the budget.
+const defaultMaxTokens = 100
+
+// RetryBudget limits retries across calls, usually to the same dependency.
+//
+// Every successful call deposits Ratio of a retry into the budget and every retry withdraws one.
+// Besides, MinPerSecond retries are allowed every second regardless of deposits.
+// For example, the budget with Ratio 0.1 and MinPerSecond 10 allows retries to be at most 10% of successful calls
+// plus 10 retries per second.
+//
+// The budget is safe for concurrent use and must not be copied after first use.
+type RetryBudget struct {
+	// Ratio of retries to successful calls.
+	Ratio float64
+	// MinPerSecond is the count of retries allowed per second regardless of the ratio.
+	MinPerSecond int
+	// MaxTokens is the maximum of accumulated retries, 100 by default.
+	MaxTokens float64
+	// Clock is a time source, SystemClock by default.
+	Clock Clock
+
+	mu       sync.Mutex
+	tokens   float64
+	reserve  float64
+	refilled time.Time
+}
+
+// NewRetryBudget creates the retry budget.
+func NewRetryBudget(ratio float64, minPerSecond int) *RetryBudget {
+	return &RetryBudget{Ratio: ratio, MinPerSecond: minPerSecond}
+}
+
+// Deposit reports a successful call.
+func (b *RetryBudget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += b.Ratio
+	if maxTokens := b.maxTokens(); b.tokens > maxTokens {
+		b.tokens = maxTokens
+	}
+}
+
+// Withdraw takes a retry from the budget. It returns false if the budget is exhausted.
+func (b *RetryBudget) Withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill()
+	switch {
+	case b.reserve >= 1:
+		b.reserve--
+	case b.tokens >= 1:
+		b.tokens--
+	default:
+		return false
+	}
+	return true
+}
+
+func (b *RetryBudget) refill() {
+	now := clockOrDefault(b.Clock).Now()
+	if b.refilled.IsZero() {
+		b.reserve = float64(b.MinPerSecond)
+	} else {
+		b.reserve += now.Sub(b.refilled).Seconds() * float64(b.MinPerSecond)
+		if b.reserve > float64(b.MinPerSecond) {
+			b.reserve = float64(b.MinPerSecond)
+		}
+	}
+	b.refilled = now
+}
+
+func (b *RetryBudget) maxTokens() float64 {
+	if b.MaxTokens <= 0 {
+		return defaultMaxTokens
+	}
+	return b.MaxTokens
+}
@@ -0,0 +1,138 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	b := NewRetryBudget(0.5, 2)
+	b.Clock = clock
+
+	// Minimum per second.
+	for i := 0; i < 2; i++ {
+		if !b.Withdraw() {
+			t.Fatalf("withdraw %d: expected retry to be allowed", i)
+		}
+	}
+	if b.Withdraw() {
+		t.Fatal("expected budget to be exhausted")
+	}
+
+	// Successes.
+	b.Deposit()
+	b.Deposit()
+	if !b.Withdraw() {
+		t.Fatal("expected retry to be allowed")
+	}
+	if b.Withdraw() {
+		t.Fatal("expected budget to be exhausted")
+	}
+
+	// Minimum is refilled with time.
+	clock.Advance(500 * time.Millisecond)
+	if !b.Withdraw() {
+		t.Fatal("expected retry to be allowed")
+	}
+	if b.Withdraw() {
+		t.Fatal("expected budget to be exhausted")
+	}
+}
+
+func TestRetryBudget_MaxTokens(t *testing.T) {
+	t.Parallel()
+
+	b := &RetryBudget{Ratio: 1, MaxTokens: 3}
+	for i := 0; i < 10; i++ {
+		b.Deposit()
+	}
+	n := 0
+	for b.Withdraw() {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("retries want: %d, got: %d", 3, n)
+	}
+}
+
+func TestRetryBudget_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	b := NewRetryBudget(1, 0)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				b.Deposit()
+			}
+		}()
+	}
+	wg.Wait()
+
+	var mu sync.Mutex
+	n := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if b.Withdraw() {
+					mu.Lock()
+					n++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if n != 50 {
+		t.Errorf("retries want: %d, got: %d", 50, n)
+	}
+}
+
+func TestDo_Budget(t *testing.T) {
+	t.Parallel()
+
+	b := NewRetryBudget(1, 1)
+	b.Clock = NewFakeClock(time.Now())
+	b.Deposit()
+
+	count := 0
+	notified := 0
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		return errors.New("error")
+	}, WithBudget(b), WithNotify(func(err error, delay time.Duration, try int, elapsed time.Duration) {
+		notified++
+	}))
+	if !errors.Is(err, ErrBudgetExhausted) {
+		t.Errorf("want: %s, got: %v", ErrBudgetExhausted, err)
+	}
+	if count != 3 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 3)
+	}
+	if notified != 2 {
+		t.Errorf("notify calls want: %d, got: %d", 2, notified)
+	}
+
+	// Success refills the budget.
+	count = 0
+	err = Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		return nil
+	}, WithBudget(b))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if !b.Withdraw() {
+		t.Error("expected retry to be allowed")
+	}
+}
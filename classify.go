@@ -0,0 +1,81 @@
+package retry
+
+import "errors"
+
+// Predicate classifies an operation error.
+type Predicate func(err error) bool
+
+// RetryOn returns the predicate that matches errors that are any of the targets, see errors.Is.
+func RetryOn(targets ...error) Predicate {
+	return isAny(targets)
+}
+
+// StopOn returns the predicate that matches errors that are any of the targets, see errors.Is.
+func StopOn(targets ...error) Predicate {
+	return isAny(targets)
+}
+
+// RetryOnType returns the predicate that matches errors that have an error of type E in its chain, see errors.As.
+func RetryOnType[E error]() Predicate {
+	return asType[E]
+}
+
+// StopOnType returns the predicate that matches errors that have an error of type E in its chain, see errors.As.
+func StopOnType[E error]() Predicate {
+	return asType[E]
+}
+
+// IsRetryable is the predicate that matches errors marked as retryable by the errors in their chain:
+// Retryable() bool is checked first, then Temporary() bool and Timeout() bool.
+// It can be used with WithRetryIf to retry temporary errors and timeouts.
+func IsRetryable(err error) bool {
+	if e, ok := as[interface{ Retryable() bool }](err); ok {
+		return e.Retryable()
+	}
+	if e, ok := as[interface{ Temporary() bool }](err); ok && e.Temporary() {
+		return true
+	}
+	if e, ok := as[interface{ Timeout() bool }](err); ok && e.Timeout() {
+		return true
+	}
+	return false
+}
+
+// notRetryable reports whether an error in the chain implements Retryable() bool and returns false.
+// Such errors stop retrying like permanent errors, so errors can be marked as not retryable
+// without importing this package. Temporary() and Timeout() don't stop retrying,
+// because they don't tell that the error must not be retried.
+func notRetryable(err error) bool {
+	e, ok := as[interface{ Retryable() bool }](err)
+	return ok && !e.Retryable()
+}
+
+func as[E any](err error) (E, bool) {
+	var target E
+	return target, errors.As(err, &target)
+}
+
+func isAny(targets []error) Predicate {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func asType[E error](err error) bool {
+	_, ok := as[E](err)
+	return ok
+}
+
+func anyMatch(predicates []Predicate, err error) bool {
+	for _, p := range predicates {
+		if p(err) {
+			return true
+		}
+	}
+	return false
+}
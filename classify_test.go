@@ -0,0 +1,210 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPredicates(t *testing.T) {
+	t.Parallel()
+
+	errA := errors.New("a")
+	errB := errors.New("b")
+	wrapped := &fs.PathError{Op: "open", Path: "file", Err: errA}
+
+	tests := []struct {
+		name      string
+		predicate Predicate
+		err       error
+		want      bool
+	}{
+		{name: "RetryOn", predicate: RetryOn(errA, errB), err: errB, want: true},
+		{name: "RetryOn wrapped", predicate: RetryOn(errA), err: wrapped, want: true},
+		{name: "RetryOn other", predicate: RetryOn(errA), err: errB, want: false},
+		{name: "StopOn", predicate: StopOn(errA), err: errA, want: true},
+		{name: "StopOn other", predicate: StopOn(errA), err: errB, want: false},
+		{name: "RetryOnType", predicate: RetryOnType[*fs.PathError](), err: wrapped, want: true},
+		{name: "StopOnType other", predicate: StopOnType[*fs.PathError](), err: errA, want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := tt.predicate(tt.err); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo_StopIf(t *testing.T) {
+	t.Parallel()
+
+	errStop := errors.New("stop")
+	count := 0
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		if count == 3 {
+			return errStop
+		}
+		return errors.New("error")
+	}, WithStopIf(StopOn(errStop)))
+	if err != errStop {
+		t.Errorf("want: %s, got: %v", errStop, err)
+	}
+	if count != 3 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 3)
+	}
+}
+
+func TestDo_RetryIf(t *testing.T) {
+	t.Parallel()
+
+	errRetry := errors.New("retry")
+	errOther := errors.New("other")
+	count := 0
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		if count == 3 {
+			return errOther
+		}
+		return errRetry
+	}, WithRetryIf(RetryOn(errRetry)))
+	if err != errOther {
+		t.Errorf("want: %s, got: %v", errOther, err)
+	}
+	if count != 3 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 3)
+	}
+}
+
+type retryableErr bool
+
+func (e retryableErr) Error() string   { return "retryable" }
+func (e retryableErr) Retryable() bool { return bool(e) }
+
+type temporaryErr bool
+
+func (e temporaryErr) Error() string   { return "temporary" }
+func (e temporaryErr) Temporary() bool { return bool(e) }
+func (e temporaryErr) Timeout() bool   { return !bool(e) }
+
+type timeoutErr bool
+
+func (e timeoutErr) Error() string { return "timeout" }
+func (e timeoutErr) Timeout() bool { return bool(e) }
+
+func TestIsRetryable(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "Retryable", err: retryableErr(true), want: true},
+		{name: "NotRetryable wrapped", err: fmt.Errorf("wrapped: %w", retryableErr(false)), want: false},
+		{name: "Retryable first", err: fmt.Errorf("%w: %w", timeoutErr(true), retryableErr(false)), want: false},
+		{name: "Temporary", err: temporaryErr(true), want: true},
+		{name: "Timeout", err: temporaryErr(false), want: true},
+		{name: "Neither", err: timeoutErr(false), want: false},
+		{name: "DeadlineExceeded", err: context.DeadlineExceeded, want: true},
+		{name: "Unknown", err: errors.New("error"), want: false},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsRetryable(tt.err); got != tt.want {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDo_Retryable(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		if count == 3 {
+			return retryableErr(false)
+		}
+		return timeoutErr(false)
+	})
+	if err != retryableErr(false) {
+		t.Errorf("want: %s, got: %v", retryableErr(false), err)
+	}
+	if count != 3 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 3)
+	}
+
+	count = 0
+	err = Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		if count == 3 {
+			return nil
+		}
+		return retryableErr(false)
+	}, WithRetryIf(RetryOnType[retryableErr]()))
+	if err != nil || count != 3 {
+		t.Errorf("retry predicate must override the error classification, count: %d, err: %v", count, err)
+	}
+}
+
+func TestDo_NotTemporary(t *testing.T) {
+	t.Parallel()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	missing := filepath.Join(t.TempDir(), "missing")
+
+	tests := []struct {
+		name      string
+		operation func() error
+		target    any
+	}{
+		{name: "PathError", operation: func() error {
+			_, err := os.Open(missing)
+			return err
+		}, target: new(*fs.PathError)},
+		{name: "ConnectionRefused", operation: func() error {
+			conn, err := net.Dial("tcp", addr)
+			if err == nil {
+				_ = conn.Close()
+			}
+			return err
+		}, target: new(*net.OpError)},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			count := 0
+			err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+				count++
+				return tt.operation()
+			}, WithMaxRetries(5))
+			if !errors.As(err, tt.target) {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count != 6 {
+				t.Errorf("attempts want: %d, got: %d", 6, count)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	timer := clock.NewTimer(time.Minute)
+	stopped := clock.NewTimer(time.Minute)
+	if !stopped.Stop() {
+		t.Error("expected timer to be stopped")
+	}
+	if n := clock.Timers(); n != 1 {
+		t.Errorf("timers want: %d, got: %d", 1, n)
+	}
+
+	clock.Advance(59 * time.Second)
+	select {
+	case <-timer.C():
+		t.Error("timer fired too early")
+	default:
+	}
+
+	clock.Advance(time.Second)
+	select {
+	case now := <-timer.C():
+		if want := start.Add(time.Minute); !now.Equal(want) {
+			t.Errorf("want: %s, got: %s", want, now)
+		}
+	default:
+		t.Error("timer didn't fire")
+	}
+	select {
+	case <-stopped.C():
+		t.Error("stopped timer fired")
+	default:
+	}
+}
+
+func TestDo_FakeClock(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewAutoFakeClock(start)
+
+	var delays []time.Duration
+	err := Do(context.Background(), Exponential(time.Second, 2, 0), func(ctx context.Context) error {
+		return errors.New("error")
+	}, WithClock(clock), WithMaxElapsedTime(10*time.Minute), WithNotify(func(err error, delay time.Duration, try int, elapsed time.Duration) {
+		delays = append(delays, delay)
+	}))
+
+	want := []time.Duration{1 << 0, 1 << 1, 1 << 2, 1 << 3, 1 << 4, 1 << 5, 1 << 6, 1 << 7, 1 << 8, 1 << 9}
+	if len(delays) != len(want) {
+		t.Fatalf("delays want: %v, got: %v", want, delays)
+	}
+	for i := range want {
+		if delays[i] != want[i]*time.Second {
+			t.Errorf("delay %d want: %s, got: %s", i, want[i]*time.Second, delays[i])
+		}
+	}
+	e := As(err)
+	if e == nil {
+		t.Fatalf("expected retry error, got: %v", err)
+	}
+	if want := clock.Now().Sub(start); e.ElapsedTime != want {
+		t.Errorf("elapsed time want: %s, got: %s", want, e.ElapsedTime)
+	}
+}
+
+func TestDo_FakeClockManual(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	done := make(chan error)
+	go func() {
+		done <- Do(context.Background(), Constant(time.Hour), func(ctx context.Context) error {
+			return errors.New("error")
+		}, WithClock(clock), WithMaxRetries(2))
+	}()
+
+	for i := 0; i < 2; i++ {
+		clock.BlockUntil(1)
+		clock.Advance(time.Hour)
+	}
+	err := <-done
+	if e := As(err); e == nil || e.ElapsedTime != 2*time.Hour {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package retry
+
+import "time"
+
+// SequenceStrategy uses strategies one after another. When the iterator of a strategy returns StopDelay,
+// the next strategy is used. The sequence stops when the last strategy stops.
+type SequenceStrategy []Strategy
+
+// Sequence creates the strategy that uses the strategies one after another.
+// For example, three immediate retries, then five exponential ones, then a constant delay:
+//
+//	Sequence(MaxRetries(3, Zero()), MaxRetries(5, Exponential(time.Second, 2, 0)), Constant(time.Minute))
+func Sequence(strategies ...Strategy) SequenceStrategy {
+	return SequenceStrategy(strategies)
+}
+
+// Iterator returns the iterator that iterates over the iterators of the strategies in order.
+func (s SequenceStrategy) Iterator() Iterator {
+	i := 0
+	var iter Iterator
+	return func() (time.Duration, error) {
+		for i < len(s) {
+			if iter == nil {
+				iter = s[i].Iterator()
+			}
+			d, err := iter()
+			if d != StopDelay || i == len(s)-1 {
+				return d, err
+			}
+			i++
+			iter = nil
+		}
+		return StopDelay, ErrDelaysSpent
+	}
+}
+
+// Phase is a strategy used during the duration.
+type Phase struct {
+	// Duration of the phase, zero means unlimited.
+	Duration time.Duration
+	// Strategy of the phase.
+	Strategy Strategy
+}
+
+// PhasedStrategy switches strategies after the elapsed time of their phases.
+// A phase also ends when its strategy stops. The strategy stops when the last phase ends.
+type PhasedStrategy struct {
+	Phases []Phase
+	// Clock is a time source, SystemClock by default.
+	Clock Clock
+}
+
+// Phased creates the strategy that switches strategies after the elapsed time of their phases.
+// For example, constant delays of a second for a minute and then exponential ones:
+//
+//	Phased(Phase{Duration: time.Minute, Strategy: Constant(time.Second)}, Phase{Strategy: Exponential(time.Second, 2, 0)})
+func Phased(phases ...Phase) PhasedStrategy {
+	return PhasedStrategy{Phases: phases}
+}
+
+// Iterator returns the iterator that iterates over the iterators of the phases strategies.
+func (p PhasedStrategy) Iterator() Iterator {
+	clock := clockOrDefault(p.Clock)
+	start := clock.Now()
+	phaseStart := start
+	i := 0
+	var iter Iterator
+	var stopErr error = ErrDelaysSpent
+	return func() (time.Duration, error) {
+		now := clock.Now()
+		for i < len(p.Phases) {
+			phase := p.Phases[i]
+			if phase.Duration == 0 || now.Sub(phaseStart) < phase.Duration {
+				if iter == nil {
+					iter = phase.Strategy.Iterator()
+				}
+				d, err := iter()
+				if d != StopDelay {
+					return d, err
+				}
+				stopErr = err
+			} else {
+				stopErr = ErrDelaysSpent
+			}
+			i++
+			iter = nil
+			phaseStart = now
+		}
+		return StopDelay, stopErr
+	}
+}
+
+// CombinedStrategy combines the delays of two strategies element by element.
+// It stops when any of the strategies stops.
+type CombinedStrategy struct {
+	First   Strategy
+	Second  Strategy
+	Combine func(a, b time.Duration) time.Duration
+}
+
+// Max creates the strategy that uses the longer delay of two strategies.
+func Max(a, b Strategy) CombinedStrategy {
+	return CombinedStrategy{First: a, Second: b, Combine: func(a, b time.Duration) time.Duration { return max(a, b) }}
+}
+
+// Min creates the strategy that uses the shorter delay of two strategies.
+func Min(a, b Strategy) CombinedStrategy {
+	return CombinedStrategy{First: a, Second: b, Combine: func(a, b time.Duration) time.Duration { return min(a, b) }}
+}
+
+// Add creates the strategy that uses the sum of delays of two strategies.
+func Add(a, b Strategy) CombinedStrategy {
+	return CombinedStrategy{First: a, Second: b, Combine: saturatedAdd}
+}
+
+// Iterator returns the iterator that combines the delays of the strategies iterators.
+func (c CombinedStrategy) Iterator() Iterator {
+	first, second := c.First.Iterator(), c.Second.Iterator()
+	return func() (time.Duration, error) {
+		a, err := first()
+		if a == StopDelay {
+			return a, err
+		}
+		b, err := second()
+		if b == StopDelay {
+			return b, err
+		}
+		return c.Combine(a, b), nil
+	}
+}
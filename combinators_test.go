@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func iterate(s Strategy, n int, between func()) ([]time.Duration, error) {
+	next := s.Iterator()
+	var delays []time.Duration
+	for i := 0; i < n; i++ {
+		d, err := next()
+		delays = append(delays, d)
+		if d == StopDelay {
+			return delays, err
+		}
+		if between != nil {
+			between()
+		}
+	}
+	return delays, nil
+}
+
+func TestSequence(t *testing.T) {
+	t.Parallel()
+
+	s := Sequence(MaxRetries(2, Zero()), Delays{time.Second, 2 * time.Second}, MaxRetries(2, Constant(time.Minute)))
+	got, err := iterate(s, 10, nil)
+	want := []time.Duration{0, 0, time.Second, 2 * time.Second, time.Minute, time.Minute, StopDelay}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+	if err == nil {
+		t.Error("expected the stop error of the last strategy")
+	}
+
+	// Iterators are independent.
+	if got, _ := iterate(s, 1, nil); got[0] != 0 {
+		t.Errorf("got: %v, want: %v", got[0], 0)
+	}
+}
+
+func TestSequence_Empty(t *testing.T) {
+	t.Parallel()
+
+	got, err := iterate(Sequence(), 1, nil)
+	if got[0] != StopDelay || !errors.Is(err, ErrDelaysSpent) {
+		t.Errorf("unexpected delay: %v, %v", got, err)
+	}
+}
+
+func TestPhased(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	s := Phased(
+		Phase{Duration: 3 * time.Second, Strategy: Constant(time.Second)},
+		Phase{Duration: 10 * time.Second, Strategy: Delays{2 * time.Second}},
+		Phase{Duration: 2 * time.Minute, Strategy: Constant(time.Minute)},
+	)
+	s.Clock = clock
+
+	var got []time.Duration
+	next := s.Iterator()
+	for {
+		d, err := next()
+		got = append(got, d)
+		if d == StopDelay {
+			if !errors.Is(err, ErrDelaysSpent) {
+				t.Errorf("unexpected error: %v", err)
+			}
+			break
+		}
+		clock.Advance(d)
+	}
+	want := []time.Duration{
+		time.Second, time.Second, time.Second, // The first phase lasts 3 seconds.
+		2 * time.Second,          // The second phase stops with its strategy.
+		time.Minute, time.Minute, // The last phase lasts 2 minutes.
+		StopDelay,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestPhased_Unlimited(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	s := Phased(Phase{Duration: time.Second, Strategy: Zero()}, Phase{Strategy: Constant(time.Hour)})
+	s.Clock = clock
+	got, _ := iterate(s, 5, func() { clock.Advance(time.Second) })
+	want := []time.Duration{0, time.Hour, time.Hour, time.Hour, time.Hour}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+}
+
+func TestCombined(t *testing.T) {
+	t.Parallel()
+
+	a := Delays{time.Second, 5 * time.Second, 2 * time.Second}
+	b := Constant(3 * time.Second)
+	tests := []struct {
+		name     string
+		strategy Strategy
+		want     []time.Duration
+	}{
+		{name: "Max", strategy: Max(a, b), want: []time.Duration{3 * time.Second, 5 * time.Second, 3 * time.Second, StopDelay}},
+		{name: "Min", strategy: Min(b, a), want: []time.Duration{time.Second, 3 * time.Second, 2 * time.Second, StopDelay}},
+		{name: "Add", strategy: Add(a, b), want: []time.Duration{4 * time.Second, 8 * time.Second, 5 * time.Second, StopDelay}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, _ := iterate(tt.strategy, len(tt.want), nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}
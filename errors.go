@@ -1,11 +1,23 @@
 package retry
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"time"
 )
 
+// ErrAttemptTimeout is wrapped by errors of attempts that timed out, see WithAttemptTimeout.
+var ErrAttemptTimeout = errors.New("attempt timeout")
+
+// ErrCanceled is the reason of stopping when the context is done.
+var ErrCanceled = errors.New("retrying canceled")
+
+// ErrPermanent is the reason of stopping by a permanent error, see WithWrapPermanent.
+var ErrPermanent = errors.New("permanent error")
+
 // PermanentError signals that the operation should not be retried.
 type PermanentError struct {
 	Err error
@@ -28,6 +40,93 @@ func (e PermanentError) Unwrap() error {
 	return e.Err
 }
 
+// DelayHinter is implemented by errors that specify the delay before the next retry,
+// for example, throttling errors with the delay got from a server.
+type DelayHinter interface {
+	RetryDelay() time.Duration
+}
+
+// RetryAfterError signals that the operation should be retried after the delay.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// RetryAfter wraps the error with the delay after which the operation should be retried.
+// The delay replaces the strategy delay, see WithRetryAfterMode and WithMaxRetryAfter.
+func RetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return RetryAfterError{Err: err, Delay: d}
+}
+
+func (e RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+func (e RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryDelay returns the delay before the next retry.
+func (e RetryAfterError) RetryDelay() time.Duration {
+	return e.Delay
+}
+
+// AttemptRecord is information about a failed attempt.
+type AttemptRecord struct {
+	// Number of the attempt starting from 1.
+	Number int
+	// Start time of the attempt.
+	Start time.Time
+	// Duration of the operation call.
+	Duration time.Duration
+	// Delay that followed the attempt, zero for the last attempt.
+	Delay time.Duration
+	// Err is the operation error without the permanent and retry after wrappers.
+	Err error
+}
+
+// maxAttemptRecords is the count of the first and the count of the last attempts kept in the history.
+const maxAttemptRecords = 10
+
+// attemptHistory keeps the records of the first and the last attempts,
+// so endless retrying doesn't keep every error alive.
+type attemptHistory struct {
+	records []AttemptRecord
+	dropped int
+}
+
+func (h *attemptHistory) add(r AttemptRecord) {
+	var perm PermanentError
+	if errors.As(r.Err, &perm) {
+		r.Err = perm.Err
+	}
+	var retryAfter RetryAfterError
+	if errors.As(r.Err, &retryAfter) {
+		r.Err = retryAfter.Err
+	}
+	if len(h.records) == 2*maxAttemptRecords {
+		h.records = append(h.records[:maxAttemptRecords], h.records[maxAttemptRecords+1:]...)
+		h.dropped++
+	}
+	h.records = append(h.records, r)
+}
+
+// last returns the record of the last attempt.
+func (h *attemptHistory) last() *AttemptRecord {
+	return &h.records[len(h.records)-1]
+}
+
+// lastErr returns the error of the last attempt or nil if there are no attempts.
+func (h *attemptHistory) lastErr() error {
+	if len(h.records) == 0 {
+		return nil
+	}
+	return h.last().Err
+}
+
 // Error wraps the original error and contains information about the last retry.
 type Error struct {
 	LastDelay   time.Duration
@@ -35,22 +134,40 @@ type Error struct {
 	Retries     int
 	Msg         string
 	Err         error
+	// Reason is a reason of stopping: the stop error of the strategy, for example ErrMaxRetries,
+	// ErrCanceled if the context is done, or ErrCircuitOpen.
+	Reason error
+	// Cause is the cause of the context cancellation got by context.Cause, if the context is done.
+	Cause error
+
+	// causeIsErr is set if Cause is the context error that is already Err.
+	causeIsErr bool
+	history    attemptHistory
 }
 
-func newError(err, ctxErr error, msg string, retries int, lastDelay time.Duration, elapsed time.Duration) error {
+func newError(err, ctxErr, cause, reason error, msg string, retries int, lastDelay time.Duration, elapsed time.Duration, history attemptHistory) error {
+	if ctxErr != nil && reason == nil {
+		reason = ErrCanceled
+	}
 	e := &Error{
 		ElapsedTime: elapsed,
 		Retries:     retries,
 		LastDelay:   lastDelay,
 		Err:         err,
+		Reason:      reason,
+		Cause:       cause,
+		history:     history,
 	}
 	switch {
 	case ctxErr != nil && err == nil:
 		e.Msg = fmt.Sprintf("retrying %d canceled, time elapsed: %s, last delay: %s", retries, elapsed, lastDelay)
 		e.Err = ctxErr
+		// Without a cause context.Cause returns the context error. The context errors are comparable,
+		// so the comparison is safe for any cause.
+		e.causeIsErr = cause == ctxErr
 	case ctxErr != nil && err != nil:
 		e.Msg = fmt.Sprintf("retrying %d canceled: %s, time elapsed: %s, last delay: %s", retries, ctxErr.Error(), elapsed, lastDelay)
-	case ctxErr == nil && err != nil:
+	case ctxErr == nil && (err != nil || reason != nil):
 		e.Msg = fmt.Sprintf("retrying %d stopped, time elapsed: %s, last delay: %s", retries, elapsed, lastDelay)
 	default:
 		return nil
@@ -68,8 +185,117 @@ func (e *Error) Error() string {
 	return e.Msg + ": " + e.Err.Error()
 }
 
-func (e *Error) Unwrap() error {
-	return e.Err
+// Unwrap returns the original error, the reason of stopping, the cause of the context cancellation
+// and the errors of the kept attempts, so errors.Is and errors.As match any of them.
+func (e *Error) Unwrap() []error {
+	errs := make([]error, 0, len(e.history.records)+3)
+	for _, err := range []error{e.Err, e.Reason} {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if e.Cause != nil && !e.causeIsErr {
+		errs = append(errs, e.Cause)
+	}
+	// The last kept attempt is the one of Err, so it's skipped by position:
+	// errors can't be compared, they may hold uncomparable values.
+	records := e.history.records
+	if len(records) > 0 {
+		records = records[:len(records)-1]
+	}
+	for _, a := range records {
+		if a.Err != nil {
+			errs = append(errs, a.Err)
+		}
+	}
+	return errs
+}
+
+// Attempts returns the history of failed attempts.
+// Only the first and the last attempts are kept, see DroppedAttempts.
+func (e *Error) Attempts() []AttemptRecord {
+	return e.history.records
+}
+
+// DroppedAttempts returns the count of attempts dropped from the middle of the history.
+func (e *Error) DroppedAttempts() int {
+	return e.history.dropped
+}
+
+// Format formats the error. The %+v verb prints the retrying statistics and the history of attempts,
+// other verbs format the error message.
+func (e *Error) Format(s fmt.State, verb rune) {
+	if verb != 'v' || !s.Flag('+') {
+		fmt.Fprintf(s, fmt.FormatString(s, verb), e.Error())
+		return
+	}
+	_, _ = io.WriteString(s, e.Error())
+	fmt.Fprintf(s, "\n\tretries: %d, elapsed: %s, last delay: %s, reason: %v", e.Retries, e.ElapsedTime, e.LastDelay, e.Reason)
+	if e.Cause != nil {
+		fmt.Fprintf(s, ", cause: %v", e.Cause)
+	}
+	for i, a := range e.history.records {
+		if i == maxAttemptRecords && e.history.dropped > 0 {
+			fmt.Fprintf(s, "\n\t... %d attempts dropped", e.history.dropped)
+		}
+		fmt.Fprintf(s, "\n\tattempt %d at %s, duration: %s, delay: %s: %v", a.Number, a.Start.Format(time.RFC3339Nano), a.Duration, a.Delay, a.Err)
+	}
+}
+
+// errorFields are the fields of Error in structured logs and JSON.
+// The durations are in milliseconds, so they can be indexed as numbers.
+type errorFields struct {
+	Retries     int     `json:"retries"`
+	ElapsedMs   float64 `json:"elapsed_ms"`
+	LastDelayMs float64 `json:"last_delay_ms"`
+	Reason      string  `json:"reason,omitempty"`
+	Cause       string  `json:"cause,omitempty"`
+	Error       string  `json:"error,omitempty"`
+}
+
+func (e *Error) fields() errorFields {
+	f := errorFields{
+		Retries:     e.Retries,
+		ElapsedMs:   milliseconds(e.ElapsedTime),
+		LastDelayMs: milliseconds(e.LastDelay),
+	}
+	if e.Reason != nil {
+		f.Reason = e.Reason.Error()
+	}
+	if e.Cause != nil {
+		f.Cause = e.Cause.Error()
+	}
+	if e.Err != nil {
+		f.Error = e.Err.Error()
+	}
+	return f
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// LogValue returns the retrying statistics, the reason, the cause and the original error as a group of attributes,
+// so structured logs contain them as separate fields. The fields are the same as in MarshalJSON.
+func (e *Error) LogValue() slog.Value {
+	f := e.fields()
+	attrs := []slog.Attr{
+		slog.Int("retries", f.Retries),
+		slog.Float64("elapsed_ms", f.ElapsedMs),
+		slog.Float64("last_delay_ms", f.LastDelayMs),
+	}
+	for _, a := range []slog.Attr{slog.String("reason", f.Reason), slog.String("cause", f.Cause), slog.String("error", f.Error)} {
+		if a.Value.String() != "" {
+			attrs = append(attrs, a)
+		}
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// MarshalJSON encodes the retrying statistics, the reason, the cause and the original error as separate fields.
+// The durations are encoded as numbers of milliseconds.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.fields())
 }
 
 // As returns retry Error that wrap an original operation error.
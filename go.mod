@@ -1,5 +1,5 @@
 module github.com/gotidy/retry
 
-go 1.20
+go 1.21
 
 require github.com/gotidy/lib v0.1.8
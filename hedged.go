@@ -0,0 +1,229 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gotidy/lib/ptr"
+)
+
+// DefaultMaxHedges is the default maximum count of concurrently running attempts of DoHedged.
+const DefaultMaxHedges = 3
+
+// ErrUnsupportedOption is returned by DoHedged if an option that it doesn't support is set.
+var ErrUnsupportedOption = errors.New("option is not supported")
+
+// WithMaxHedges sets the maximum count of concurrently running attempts of DoHedged, DefaultMaxHedges by default.
+// When the limit is reached the next attempt is started after a running one fails.
+func WithMaxHedges(n int) Option {
+	return func(opts *options) {
+		opts.MaxHedges = n
+	}
+}
+
+func (opts *options) maxHedges() int {
+	if opts.MaxHedges <= 0 {
+		return DefaultMaxHedges
+	}
+	return opts.MaxHedges
+}
+
+// hedgedUnsupported returns an error if the options that DoHedged doesn't support are set.
+func (opts *options) hedgedUnsupported() error {
+	var names []string
+	if opts.Breaker != nil {
+		names = append(names, "WithBreaker")
+	}
+	if opts.Budget != nil {
+		names = append(names, "WithBudget")
+	}
+	if opts.Limiter != nil {
+		names = append(names, "WithLimiter")
+	}
+	if opts.RetryAfterMode != RetryAfterReplace {
+		names = append(names, "WithRetryAfterMode")
+	}
+	if opts.MaxRetryAfter > 0 {
+		names = append(names, "WithMaxRetryAfter")
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w by DoHedged: %s", ErrUnsupportedOption, strings.Join(names, ", "))
+}
+
+type outcome[T any] struct {
+	result   T
+	err      error
+	timedOut bool
+	number   int
+	start    time.Time
+	ctx      context.Context
+}
+
+// DoHedged runs hedged attempts of the operation and returns the result of the first successful one.
+//
+// The first attempt is started immediately. Every next attempt is started after the strategy delay
+// if all running attempts haven't succeeded yet, or immediately when an attempt fails.
+// The attempts run in parallel, the total count of them is limited by the strategy,
+// for example WithMaxRetries(2) allows at most three attempts, and the count of concurrently running ones
+// is limited by WithMaxHedges. When an attempt succeeds, the contexts of the remaining attempts are canceled.
+//
+// If all attempts fail the returned *Error contains the error of the last failed attempt,
+// the errors of the other attempts kept in its history are matched by errors.Is and errors.As, see Error.Attempts.
+// A permanent error stops the hedging and is returned at once, see WithWrapPermanent.
+//
+// DoHedged supports the options that define the strategy, timeouts, clock, error classification, notify and hooks,
+// and returns ErrUnsupportedOption if the breaker, budget, limiter or retry after options are set.
+// The delays hinted by errors are ignored. OnAttemptEnd isn't called for the attempts that are canceled.
+// OnRetry and Notify are called for every failed attempt that is followed by another one with zero delay,
+// because the next attempt is started at once.
+func DoHedged[T any](ctx context.Context, strategy Strategy, operation func(ctx context.Context) (T, error), o ...Option) (result T, err error) {
+	opts := options{Strategy: strategy}
+
+	for _, opt := range o {
+		opt(&opts)
+	}
+	if err := opts.hedgedUnsupported(); err != nil {
+		return ptr.Zero[T](), err
+	}
+
+	clock := clockOrDefault(opts.Clock)
+	opts.wrapMaxElapsedTime(clock)
+
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	attemptCtx, cancelAttempts := context.WithCancel(ctx)
+	defer cancelAttempts()
+	done := make(chan struct{})
+	defer close(done)
+	outcomes := make(chan outcome[T])
+
+	start := clock.Now()
+	launched, running := 0, 0
+	if len(opts.Hooks) > 0 {
+		defer func() {
+			info := AttemptInfo{Number: launched, Elapsed: clock.Now().Sub(start), Err: err}
+			if err != nil {
+				opts.Hooks.OnGiveUp(ctx, info)
+			} else {
+				opts.Hooks.OnSuccess(ctx, info)
+			}
+		}()
+	}
+
+	next := &peekIterator{next: opts.Strategy.Iterator()}
+	var prevErr error
+	launch := func() {
+		launched++
+		running++
+		number, attemptStart := launched, clock.Now()
+		info := AttemptInfo{Number: number, Start: attemptStart, Elapsed: attemptStart.Sub(start)}
+		opCtx := withAttempt(attemptCtx, Attempt{Number: number, Elapsed: info.Elapsed, PrevErr: prevErr, more: next.more})
+		opCtx = opts.Hooks.OnAttemptStart(opCtx, info)
+		timeout := opts.attemptTimeout(ctx)
+		go func() {
+			result, timedOut, err := call(opCtx, timeout, operation)
+			select {
+			case outcomes <- outcome[T]{result: result, err: err, timedOut: timedOut, number: number, start: attemptStart, ctx: opCtx}:
+			case <-done:
+			}
+		}()
+	}
+
+	var (
+		timer     Timer
+		delay     time.Duration
+		stopErr   error
+		lastDelay time.Duration
+		delays    = map[int]time.Duration{}
+		history   attemptHistory
+	)
+	schedule := func() {
+		if timer != nil {
+			timer.Stop()
+			timer = nil
+		}
+		delay, stopErr = next.take()
+		if delay == StopDelay {
+			if stopErr == nil {
+				stopErr = ErrStopped
+			}
+			return
+		}
+		lastDelay = delay
+		delays[launched] = delay
+		timer = clock.NewTimer(delay)
+	}
+	stop := func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+	defer stop()
+
+	launch()
+	schedule()
+	for {
+		var fire <-chan time.Time
+		if timer != nil {
+			fire = timer.C()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ptr.Zero[T](), newError(history.lastErr(), ctx.Err(), context.Cause(ctx), nil, "", launched, lastDelay, clock.Now().Sub(start), history)
+		case <-fire:
+			timer = nil
+			// At the limit of running attempts the next one is started when a running one fails.
+			if running < opts.maxHedges() {
+				launch()
+				schedule()
+			}
+		case o := <-outcomes:
+			running--
+			end := clock.Now()
+			info := AttemptInfo{Number: o.number, Start: o.start, Elapsed: end.Sub(start), Duration: end.Sub(o.start), Err: o.err}
+			opts.Hooks.OnAttemptEnd(o.ctx, info)
+			if o.err == nil {
+				return o.result, nil
+			}
+			prevErr = o.err
+			history.add(AttemptRecord{Number: o.number, Start: o.start, Duration: info.Duration, Delay: delays[o.number], Err: o.err})
+			delete(delays, o.number)
+
+			if !o.timedOut {
+				var perm PermanentError
+				if ok := errors.As(o.err, &perm); ok {
+					if opts.WrapPermanent {
+						return ptr.Zero[T](), newError(perm.Err, nil, nil, ErrPermanent, ErrPermanent.Error(), launched, lastDelay, info.Elapsed, history)
+					}
+					return ptr.Zero[T](), perm
+				}
+				if opts.stop(o.err) {
+					if opts.WrapPermanent {
+						return ptr.Zero[T](), newError(o.err, nil, nil, ErrPermanent, ErrPermanent.Error(), launched, lastDelay, info.Elapsed, history)
+					}
+					return ptr.Zero[T](), o.err
+				}
+			}
+
+			if delay != StopDelay {
+				opts.Hooks.OnRetry(ctx, info)
+				if opts.Notify != nil {
+					opts.Notify(o.err, 0, o.number, info.Elapsed)
+				}
+				launch()
+				schedule()
+				continue
+			}
+			if running == 0 {
+				return ptr.Zero[T](), newError(history.lastErr(), nil, nil, stopErr, stopErr.Error(), launched, lastDelay, clock.Now().Sub(start), history)
+			}
+		}
+	}
+}
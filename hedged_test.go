@@ -0,0 +1,201 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestDoHedged(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	canceled := make(chan struct{})
+	got, err := DoHedged(context.Background(), Constant(10*time.Millisecond), func(ctx context.Context) (int, error) {
+		n := count.Add(1)
+		if n == 1 {
+			// The first attempt hangs until it is canceled.
+			<-ctx.Done()
+			close(canceled)
+			return 0, ctx.Err()
+		}
+		return int(n), nil
+	}, WithMaxRetries(3))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != 2 {
+		t.Errorf("value want: %d, got: %d", 2, got)
+	}
+
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("the first attempt wasn't canceled")
+	}
+}
+
+func TestDoHedged_Errors(t *testing.T) {
+	t.Parallel()
+
+	errs := []error{errors.New("first"), errors.New("second"), errors.New("third")}
+	var count atomic.Int32
+	_, err := DoHedged(context.Background(), Constant(time.Hour), func(ctx context.Context) (int, error) {
+		return 0, RetryAfter(errs[count.Add(1)-1], time.Minute)
+	}, WithMaxRetries(2))
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+	for _, want := range errs {
+		if !errors.Is(err, want) {
+			t.Errorf("expected %s in %s", want, err)
+		}
+	}
+	if e := As(err); e == nil || e.Retries != 3 || e.Err != errs[2] || len(e.Unwrap()) != 4 {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var hint DelayHinter
+	if errors.As(err, &hint) {
+		t.Errorf("the ignored delay hint must not be unwrapped: %v", hint)
+	}
+}
+
+func TestDoHedged_MaxAttempts(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	_, err := DoHedged(context.Background(), Zero(), func(ctx context.Context) (int, error) {
+		count.Add(1)
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, WithMaxRetries(2), WithTimeout(50*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want: %s, got: %v", context.DeadlineExceeded, err)
+	}
+	if n := count.Load(); n != 3 {
+		t.Errorf("attempts want: %d, got: %d", 3, n)
+	}
+}
+
+func TestDoHedged_Permanent(t *testing.T) {
+	t.Parallel()
+
+	want := errors.New("permanent")
+	_, err := DoHedged(context.Background(), Constant(time.Hour), func(ctx context.Context) (int, error) {
+		return 0, Permanent(want)
+	})
+	if !errors.Is(err, want) {
+		t.Errorf("want: %s, got: %v", want, err)
+	}
+}
+
+func TestDoHedged_WrapPermanent(t *testing.T) {
+	t.Parallel()
+
+	want := errors.New("permanent")
+	_, err := DoHedged(context.Background(), Constant(time.Hour), func(ctx context.Context) (int, error) {
+		return 0, Permanent(want)
+	}, WithWrapPermanent())
+	e := As(err)
+	if e == nil || e.Err != want || e.Reason != ErrPermanent || e.Retries != 1 {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDoHedged_Cause(t *testing.T) {
+	t.Parallel()
+
+	cause := errors.New("cause")
+	_, err := DoHedged(context.Background(), Constant(time.Hour), func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, WithTimeout(time.Millisecond, cause))
+	if e := As(err); e == nil || e.Cause != cause || !errors.Is(err, cause) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestDoHedged_MaxHedges(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		opts []Option
+		want int32
+	}{
+		{name: "Default", want: DefaultMaxHedges},
+		{name: "WithMaxHedges", opts: []Option{WithMaxHedges(2)}, want: 2},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var count atomic.Int32
+			_, err := DoHedged(context.Background(), Zero(), func(ctx context.Context) (int, error) {
+				count.Add(1)
+				<-ctx.Done()
+				return 0, ctx.Err()
+			}, append(tt.opts, WithTimeout(50*time.Millisecond))...)
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("want: %s, got: %v", context.DeadlineExceeded, err)
+			}
+			if n := count.Load(); n != tt.want {
+				t.Errorf("attempts want: %d, got: %d", tt.want, n)
+			}
+		})
+	}
+}
+
+func TestDoHedged_UnsupportedOption(t *testing.T) {
+	t.Parallel()
+
+	called := false
+	_, err := DoHedged(context.Background(), Zero(), func(ctx context.Context) (int, error) {
+		called = true
+		return 0, nil
+	}, WithBreaker(NewCircuitBreaker(1, time.Minute)), WithMaxRetryAfter(time.Second))
+	if !errors.Is(err, ErrUnsupportedOption) || !strings.Contains(err.Error(), "WithBreaker, WithMaxRetryAfter") {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if called {
+		t.Error("the operation must not be called")
+	}
+}
+
+func TestDoHedged_AttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	var notified []int
+	var count atomic.Int32
+	got, err := DoHedged(context.Background(), Constant(time.Hour), func(ctx context.Context) (int, error) {
+		n := count.Add(1)
+		a, ok := AttemptFromContext(ctx)
+		if !ok || a.Number != int(n) {
+			return 0, Permanent(fmt.Errorf("unexpected attempt: %v", a))
+		}
+		if n == 1 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		if !errors.Is(a.PrevErr, ErrAttemptTimeout) {
+			return 0, Permanent(fmt.Errorf("unexpected previous error: %v", a.PrevErr))
+		}
+		return int(n), nil
+	}, WithAttemptTimeout(10*time.Millisecond), WithNotify(func(err error, delay time.Duration, try int, elapsed time.Duration) {
+		notified = append(notified, try)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got != 2 {
+		t.Errorf("value want: %d, got: %d", 2, got)
+	}
+	if len(notified) != 1 || notified[0] != 1 {
+		t.Errorf("unexpected notifications: %v", notified)
+	}
+}
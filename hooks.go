@@ -0,0 +1,104 @@
+package retry
+
+import (
+	"context"
+	"time"
+)
+
+// AttemptInfo is information about an attempt passed to hooks.
+type AttemptInfo struct {
+	// Number of the attempt starting from 1. In OnGiveUp and OnSuccess it's the count of attempts.
+	Number int
+	// Start time of the attempt.
+	Start time.Time
+	// Elapsed is the time elapsed since the retrying started.
+	Elapsed time.Duration
+	// Duration of the operation call. It's set in OnAttemptEnd and OnRetry.
+	Duration time.Duration
+	// Delay before the next attempt. It's set in OnRetry.
+	Delay time.Duration
+	// Err is the operation error in OnAttemptEnd and OnRetry, and the returned error in OnGiveUp.
+	Err error
+}
+
+// Hooks observes the retrying lifecycle, for example to trace, to collect metrics or to log.
+type Hooks interface {
+	// OnAttemptStart is called before every operation call with the attempt context.
+	// It returns the context passed to the operation and OnAttemptEnd, ctx or a context derived from it,
+	// for example with a tracing span that becomes the parent of the operation calls.
+	OnAttemptStart(ctx context.Context, info AttemptInfo) context.Context
+	// OnAttemptEnd is called after every operation call with the context returned by OnAttemptStart.
+	OnAttemptEnd(ctx context.Context, info AttemptInfo)
+	// OnRetry is called when a failed attempt is going to be retried after the delay.
+	OnRetry(ctx context.Context, info AttemptInfo)
+	// OnGiveUp is called when the retrying stops with an error.
+	OnGiveUp(ctx context.Context, info AttemptInfo)
+	// OnSuccess is called when the operation succeeds.
+	OnSuccess(ctx context.Context, info AttemptInfo)
+}
+
+// NopHooks does nothing. Embed it to implement only the needed hooks.
+type NopHooks struct{}
+
+// OnAttemptStart returns the context as is.
+func (NopHooks) OnAttemptStart(ctx context.Context, _ AttemptInfo) context.Context { return ctx }
+
+// OnAttemptEnd does nothing.
+func (NopHooks) OnAttemptEnd(context.Context, AttemptInfo) {}
+
+// OnRetry does nothing.
+func (NopHooks) OnRetry(context.Context, AttemptInfo) {}
+
+// OnGiveUp does nothing.
+func (NopHooks) OnGiveUp(context.Context, AttemptInfo) {}
+
+// OnSuccess does nothing.
+func (NopHooks) OnSuccess(context.Context, AttemptInfo) {}
+
+// MultiHooks calls all hooks in order.
+type MultiHooks []Hooks
+
+// OnAttemptStart calls OnAttemptStart of all hooks, every hook gets the context returned by the previous one.
+func (m MultiHooks) OnAttemptStart(ctx context.Context, info AttemptInfo) context.Context {
+	for _, h := range m {
+		if c := h.OnAttemptStart(ctx, info); c != nil {
+			ctx = c
+		}
+	}
+	return ctx
+}
+
+// OnAttemptEnd calls OnAttemptEnd of all hooks.
+func (m MultiHooks) OnAttemptEnd(ctx context.Context, info AttemptInfo) {
+	for _, h := range m {
+		h.OnAttemptEnd(ctx, info)
+	}
+}
+
+// OnRetry calls OnRetry of all hooks.
+func (m MultiHooks) OnRetry(ctx context.Context, info AttemptInfo) {
+	for _, h := range m {
+		h.OnRetry(ctx, info)
+	}
+}
+
+// OnGiveUp calls OnGiveUp of all hooks.
+func (m MultiHooks) OnGiveUp(ctx context.Context, info AttemptInfo) {
+	for _, h := range m {
+		h.OnGiveUp(ctx, info)
+	}
+}
+
+// OnSuccess calls OnSuccess of all hooks.
+func (m MultiHooks) OnSuccess(ctx context.Context, info AttemptInfo) {
+	for _, h := range m {
+		h.OnSuccess(ctx, info)
+	}
+}
+
+// WithHooks adds the hooks. Hooks added by several options are called in order.
+func WithHooks(h ...Hooks) Option {
+	return func(opts *options) {
+		opts.Hooks = append(opts.Hooks, h...)
+	}
+}
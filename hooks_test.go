@@ -0,0 +1,137 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type recordHooks struct {
+	NopHooks
+	name   string
+	events *[]string
+}
+
+func (h recordHooks) add(event string, info AttemptInfo) {
+	*h.events = append(*h.events, fmt.Sprintf("%s %s %d %s %s", h.name, event, info.Number, info.Elapsed, info.Delay))
+}
+
+func (h recordHooks) OnAttemptStart(ctx context.Context, info AttemptInfo) context.Context {
+	h.add("start", info)
+	return ctx
+}
+
+func (h recordHooks) OnAttemptEnd(_ context.Context, info AttemptInfo) { h.add("end", info) }
+
+func (h recordHooks) OnRetry(_ context.Context, info AttemptInfo) { h.add("retry", info) }
+
+func (h recordHooks) OnGiveUp(_ context.Context, info AttemptInfo) { h.add("giveup", info) }
+
+func (h recordHooks) OnSuccess(_ context.Context, info AttemptInfo) { h.add("success", info) }
+
+func TestDo_Hooks(t *testing.T) {
+	t.Parallel()
+
+	var events []string
+	count := 0
+	err := Do(context.Background(), Constant(time.Second), func(ctx context.Context) error {
+		count++
+		if count == 2 {
+			return nil
+		}
+		return errors.New("error")
+	}, WithClock(NewAutoFakeClock(time.Now())), WithHooks(recordHooks{name: "a", events: &events}), WithHooks(recordHooks{name: "b", events: &events}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []string{
+		"a start 1 0s 0s", "b start 1 0s 0s",
+		"a end 1 0s 0s", "b end 1 0s 0s",
+		"a retry 1 0s 1s", "b retry 1 0s 1s",
+		"a start 2 1s 0s", "b start 2 1s 0s",
+		"a end 2 1s 0s", "b end 2 1s 0s",
+		"a success 2 1s 0s", "b success 2 1s 0s",
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events want: %v, got: %v", want, events)
+	}
+}
+
+func TestDo_HooksGiveUp(t *testing.T) {
+	t.Parallel()
+
+	var events []string
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		return Permanent(errors.New("error"))
+	}, WithClock(NewFakeClock(time.Now())), WithHooks(MultiHooks{recordHooks{name: "a", events: &events}}))
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	want := []string{"a start 1 0s 0s", "a end 1 0s 0s", "a giveup 1 0s 0s"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events want: %v, got: %v", want, events)
+	}
+}
+
+func TestDoHedged_Hooks(t *testing.T) {
+	t.Parallel()
+
+	var events []string
+	_, err := DoHedged(context.Background(), Zero(), func(ctx context.Context) (int, error) {
+		return 0, errors.New("error")
+	}, WithClock(NewAutoFakeClock(time.Now())), WithMaxRetries(1), WithHooks(recordHooks{name: "a", events: &events}))
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+	if last := events[len(events)-1]; last != "a giveup 2 0s 0s" {
+		t.Errorf("unexpected last event: %s", last)
+	}
+}
+
+type spanKey struct{}
+
+type spanHooks struct {
+	NopHooks
+	ended *[]int
+}
+
+func (h spanHooks) OnAttemptStart(ctx context.Context, info AttemptInfo) context.Context {
+	return context.WithValue(ctx, spanKey{}, info.Number)
+}
+
+func (h spanHooks) OnAttemptEnd(ctx context.Context, info AttemptInfo) {
+	if span, _ := ctx.Value(spanKey{}).(int); span == info.Number {
+		*h.ended = append(*h.ended, span)
+	}
+}
+
+func TestDo_HooksContext(t *testing.T) {
+	t.Parallel()
+
+	var ended []int
+	count := 0
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		if span, _ := ctx.Value(spanKey{}).(int); span != count {
+			return Permanent(fmt.Errorf("span want: %d, got: %d", count, span))
+		}
+		if a, ok := AttemptFromContext(ctx); !ok || a.Number != count {
+			return Permanent(fmt.Errorf("unexpected attempt: %v", a))
+		}
+		if count == 2 {
+			return nil
+		}
+		return errors.New("error")
+	}, WithHooks(spanHooks{ended: &ended}, NopHooks{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if want := []int{1, 2}; !reflect.DeepEqual(ended, want) {
+		t.Errorf("ended spans want: %v, got: %v", want, ended)
+	}
+}
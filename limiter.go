@@ -0,0 +1,90 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Limiter limits the rate of retry attempts.
+type Limiter interface {
+	// Wait blocks until an attempt is allowed or the context is done.
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a token bucket rate limiter. The bucket starts full.
+//
+// The bucket is safe for concurrent use and must not be copied after first use.
+type TokenBucket struct {
+	// Rate is the count of tokens added per second, it must be positive.
+	Rate float64
+	// Burst is the bucket size, 1 by default.
+	Burst int
+	// Clock is a time source, SystemClock by default.
+	Clock Clock
+
+	mu       sync.Mutex
+	tokens   float64
+	refilled time.Time
+}
+
+// NewTokenBucket creates the token bucket rate limiter.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	return &TokenBucket{Rate: rate, Burst: burst}
+}
+
+// Wait takes a token from the bucket, waiting for it if the bucket is empty.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	clock := clockOrDefault(b.Clock)
+	wait := b.reserve(clock.Now())
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := clock.NewTimer(wait)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		b.cancel()
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
+
+// reserve takes a token and returns the time to wait until it's available.
+func (b *TokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	burst := float64(b.burst())
+	if b.refilled.IsZero() {
+		b.tokens = burst
+	} else {
+		b.tokens += now.Sub(b.refilled).Seconds() * b.Rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+	}
+	b.refilled = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.Rate * float64(time.Second))
+}
+
+// cancel returns the reserved token.
+func (b *TokenBucket) cancel() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens++
+}
+
+func (b *TokenBucket) burst() int {
+	if b.Burst <= 0 {
+		return 1
+	}
+	return b.Burst
+}
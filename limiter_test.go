@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	b := NewTokenBucket(2, 2)
+	b.Clock = clock
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if err := b.Wait(ctx); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+	}
+
+	done := make(chan error)
+	go func() {
+		done <- b.Wait(ctx)
+	}()
+	clock.BlockUntil(1)
+	clock.Advance(500 * time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+}
+
+func TestTokenBucket_Canceled(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	b := NewTokenBucket(1, 1)
+	b.Clock = clock
+
+	if err := b.Wait(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("want: %s, got: %v", context.Canceled, err)
+	}
+
+	// The canceled reservation is returned.
+	clock.Advance(time.Second)
+	if wait := b.reserve(clock.Now()); wait != 0 {
+		t.Errorf("unexpected wait: %s", wait)
+	}
+}
+
+func TestDo_Limiter(t *testing.T) {
+	t.Parallel()
+
+	clock := NewAutoFakeClock(time.Now())
+	l := NewTokenBucket(1, 1)
+	l.Clock = clock
+
+	var elapsed []time.Duration
+	count := 0
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		a, _ := AttemptFromContext(ctx)
+		elapsed = append(elapsed, a.Elapsed)
+		return errors.New("error")
+	}, WithClock(clock), WithLimiter(l), WithMaxRetries(3))
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+	if count != 4 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 4)
+	}
+
+	// The bucket is full at start, so the first retry isn't delayed.
+	want := []time.Duration{0, 0, time.Second, 2 * time.Second}
+	if len(elapsed) != len(want) {
+		t.Fatalf("elapsed want: %v, got: %v", want, elapsed)
+	}
+	for i := range want {
+		if elapsed[i] != want[i] {
+			t.Errorf("elapsed %d want: %s, got: %s", i, want[i], elapsed[i])
+		}
+	}
+	if e := As(err); e == nil || e.ElapsedTime != 2*time.Second {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package retry
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Logger logs retries, giving up and success after retries with log/slog.
+type Logger struct {
+	// Logger is the logger, slog.Default() by default.
+	Logger *slog.Logger
+	// Operation is the operation name.
+	Operation string
+	// RetryLevel is the level of retry records.
+	RetryLevel slog.Level
+	// GiveUpLevel is the level of giving up records.
+	GiveUpLevel slog.Level
+	// SuccessLevel is the level of success after retries records.
+	SuccessLevel slog.Level
+	// First is the count of the first retries that are always logged.
+	First int
+	// Every sets logging of every Every-th retry after the First ones.
+	// If both First and Every are zero, all retries are logged.
+	Every int
+}
+
+// NewLogger creates the logger with warn level of retries, error level of giving up and info level of success.
+func NewLogger(l *slog.Logger, operation string) *Logger {
+	return &Logger{
+		Logger:       l,
+		Operation:    operation,
+		RetryLevel:   slog.LevelWarn,
+		GiveUpLevel:  slog.LevelError,
+		SuccessLevel: slog.LevelInfo,
+	}
+}
+
+// WithLogger adds the logger hooks.
+func WithLogger(l *Logger) Option {
+	return WithHooks(l)
+}
+
+func (l *Logger) logger() *slog.Logger {
+	if l.Logger == nil {
+		return slog.Default()
+	}
+	return l.Logger
+}
+
+func (l *Logger) sampled(try int) bool {
+	if l.First == 0 && l.Every == 0 {
+		return true
+	}
+	if try <= l.First {
+		return true
+	}
+	return l.Every > 0 && (try-l.First)%l.Every == 0
+}
+
+// OnAttemptStart returns the context as is.
+func (l *Logger) OnAttemptStart(ctx context.Context, _ AttemptInfo) context.Context { return ctx }
+
+// OnAttemptEnd does nothing.
+func (l *Logger) OnAttemptEnd(context.Context, AttemptInfo) {}
+
+// OnRetry logs the retry.
+func (l *Logger) OnRetry(ctx context.Context, info AttemptInfo) {
+	if !l.sampled(info.Number) {
+		return
+	}
+	l.logger().LogAttrs(ctx, l.RetryLevel, "retrying operation",
+		slog.String("operation", l.Operation),
+		slog.Int("attempt", info.Number),
+		slog.Duration("delay", info.Delay),
+		slog.Duration("elapsed", info.Elapsed),
+		slog.Any("error", info.Err),
+	)
+}
+
+// OnGiveUp logs giving up.
+func (l *Logger) OnGiveUp(ctx context.Context, info AttemptInfo) {
+	l.logger().LogAttrs(ctx, l.GiveUpLevel, "operation failed, giving up",
+		slog.String("operation", l.Operation),
+		slog.Int("attempt", info.Number),
+		slog.Duration("elapsed", info.Elapsed),
+		slog.Any("error", info.Err),
+	)
+}
+
+// OnSuccess logs success if the operation was retried.
+func (l *Logger) OnSuccess(ctx context.Context, info AttemptInfo) {
+	if info.Number <= 1 {
+		return
+	}
+	l.logger().LogAttrs(ctx, l.SuccessLevel, "operation succeeded after retries",
+		slog.String("operation", l.Operation),
+		slog.Int("attempt", info.Number),
+		slog.Duration("elapsed", info.Elapsed),
+	)
+}
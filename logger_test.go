@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+)
+
+type captureHandler struct {
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *captureHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+func (h *captureHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *captureHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *captureHandler) WithGroup(string) slog.Handler { return h }
+
+func recordAttrs(r slog.Record) map[string]slog.Value {
+	attrs := map[string]slog.Value{}
+	r.Attrs(func(a slog.Attr) bool {
+		attrs[a.Key] = a.Value
+		return true
+	})
+	return attrs
+}
+
+func TestLogger(t *testing.T) {
+	t.Parallel()
+
+	h := &captureHandler{}
+	count := 0
+	err := Do(context.Background(), Constant(time.Second), func(ctx context.Context) error {
+		count++
+		if count == 3 {
+			return nil
+		}
+		return errors.New("error")
+	}, WithClock(NewAutoFakeClock(time.Now())), WithLogger(NewLogger(slog.New(h), "get")))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(h.records) != 3 {
+		t.Fatalf("records want: %d, got: %d", 3, len(h.records))
+	}
+	for i, r := range h.records[:2] {
+		if r.Level != slog.LevelWarn {
+			t.Errorf("record %d level want: %s, got: %s", i, slog.LevelWarn, r.Level)
+		}
+		attrs := recordAttrs(r)
+		if attrs["operation"].String() != "get" || attrs["attempt"].Int64() != int64(i+1) ||
+			attrs["delay"].Duration() != time.Second || attrs["elapsed"].Duration() != time.Duration(i)*time.Second {
+			t.Errorf("record %d unexpected attributes: %v", i, attrs)
+		}
+	}
+	success := h.records[2]
+	if success.Level != slog.LevelInfo || recordAttrs(success)["attempt"].Int64() != 3 {
+		t.Errorf("unexpected success record: %v", success)
+	}
+}
+
+func TestLogger_GiveUp(t *testing.T) {
+	t.Parallel()
+
+	h := &captureHandler{}
+	l := NewLogger(slog.New(h), "get")
+	l.GiveUpLevel = slog.LevelWarn + 1
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		return errors.New("error")
+	}, WithLogger(l), WithMaxRetries(1))
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+
+	if len(h.records) != 2 {
+		t.Fatalf("records want: %d, got: %d", 2, len(h.records))
+	}
+	giveUp := h.records[1]
+	if giveUp.Level != slog.LevelWarn+1 {
+		t.Errorf("level want: %s, got: %s", slog.LevelWarn+1, giveUp.Level)
+	}
+	if got := recordAttrs(giveUp)["error"].Any(); got != err {
+		t.Errorf("error want: %v, got: %v", err, got)
+	}
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	t.Parallel()
+
+	l := &Logger{First: 3, Every: 10}
+	var sampled []int
+	for try := 1; try <= 35; try++ {
+		if l.sampled(try) {
+			sampled = append(sampled, try)
+		}
+	}
+	want := []int{1, 2, 3, 13, 23, 33}
+	if len(sampled) != len(want) {
+		t.Fatalf("sampled want: %v, got: %v", want, sampled)
+	}
+	for i := range want {
+		if sampled[i] != want[i] {
+			t.Errorf("sampled want: %v, got: %v", want, sampled)
+			break
+		}
+	}
+
+	if !(&Logger{}).sampled(100) {
+		t.Error("expected all retries to be logged")
+	}
+	if (&Logger{First: 1}).sampled(2) {
+		t.Error("expected only the first retry to be logged")
+	}
+}
@@ -4,6 +4,7 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/gotidy/lib/ptr"
@@ -21,10 +22,38 @@ type options struct {
 	MaxRetries int
 	// After Timeout the context will canceled.
 	Timeout time.Duration
-	// After MaxElapsedTime the retrying will stopped.
-	MaxElapsedTime time.Duration
+	// TimeoutCause is the cause of the context cancellation by Timeout.
+	TimeoutCause error
+	// After MaxElapsedTime the retrying will stopped. Every value wraps the strategy.
+	MaxElapsedTime []time.Duration
 	// Notify
 	Notify Notify
+	// Clock is a time source, SystemClock by default.
+	Clock Clock
+	// RetryIf are predicates of errors that should be retried.
+	RetryIf []Predicate
+	// StopIf are predicates of errors that should not be retried.
+	StopIf []Predicate
+	// WrapPermanent wraps permanent errors with Error.
+	WrapPermanent bool
+	// RetryAfterMode is a mode of using delays hinted by errors.
+	RetryAfterMode RetryAfterMode
+	// MaxRetryAfter is a maximum of delays hinted by errors.
+	MaxRetryAfter time.Duration
+	// Breaker is a circuit breaker.
+	Breaker *CircuitBreaker
+	// Budget is a retry budget.
+	Budget *RetryBudget
+	// Limiter is a rate limiter of retry attempts.
+	Limiter Limiter
+	// AttemptTimeout is a timeout of every attempt.
+	AttemptTimeout time.Duration
+	// AttemptTimeoutFraction is a fraction of the remaining context deadline used as a timeout of every attempt.
+	AttemptTimeoutFraction float64
+	// Hooks observe the retrying.
+	Hooks MultiHooks
+	// MaxHedges is the maximum count of concurrently running attempts of DoHedged.
+	MaxHedges int
 
 	Strategy Strategy
 }
@@ -40,9 +69,13 @@ func WithMaxRetries(n int) Option {
 }
 
 // WithTimeout sets timeout.
-func WithTimeout(d time.Duration) Option {
+// The optional cause is set as the cause of the context cancellation by the timeout, see context.WithTimeoutCause.
+func WithTimeout(d time.Duration, cause ...error) Option {
 	return func(opts *options) {
 		opts.Timeout = d
+		if len(cause) > 0 {
+			opts.TimeoutCause = cause[0]
+		}
 	}
 }
 
@@ -50,7 +83,7 @@ func WithTimeout(d time.Duration) Option {
 // Time after which retrying are stopped.
 func WithMaxElapsedTime(d time.Duration) Option {
 	return func(opts *options) {
-		opts.Strategy = MaxElapsedTimeWrapper{MaxElapsedTime: d}.Wrap(opts.Strategy)
+		opts.MaxElapsedTime = append(opts.MaxElapsedTime, d)
 	}
 }
 
@@ -61,8 +94,215 @@ func WithNotify(n Notify) Option {
 	}
 }
 
+// WithClock sets the clock that is used for measuring elapsed time and waiting delays.
+// The clock doesn't affect WithTimeout, the timeout is handled by the context.
+func WithClock(c Clock) Option {
+	return func(opts *options) {
+		opts.Clock = c
+	}
+}
+
+// WithRetryIf adds the predicate of errors that should be retried.
+// If any retry predicate is set, errors that match none of them stop retrying,
+// and errors aren't classified by their Retryable() method.
+func WithRetryIf(p Predicate) Option {
+	return func(opts *options) {
+		opts.RetryIf = append(opts.RetryIf, p)
+	}
+}
+
+// WithStopIf adds the predicate of errors that should not be retried,
+// such errors stop retrying like permanent errors.
+func WithStopIf(p Predicate) Option {
+	return func(opts *options) {
+		opts.StopIf = append(opts.StopIf, p)
+	}
+}
+
+// WithWrapPermanent wraps permanent errors and errors stopped by the classification with *Error
+// that keeps the retrying statistics. The Error has ErrPermanent reason and unwraps to the original error.
+// By default such errors are returned as is.
+func WithWrapPermanent() Option {
+	return func(opts *options) {
+		opts.WrapPermanent = true
+	}
+}
+
+// WithBreaker sets the circuit breaker. Every attempt is checked by the breaker and its result is reported to it.
+// While the breaker is open the retrying is stopped with ErrCircuitOpen reason.
+// The breaker is usually shared by all calls to the same dependency.
+func WithBreaker(b *CircuitBreaker) Option {
+	return func(opts *options) {
+		opts.Breaker = b
+	}
+}
+
+// WithBudget sets the retry budget. Every retry is withdrawn from the budget and every success deposits to it.
+// When the budget is exhausted the retrying is stopped with ErrBudgetExhausted reason.
+// The budget is usually shared by all calls to the same dependency.
+func WithBudget(b *RetryBudget) Option {
+	return func(opts *options) {
+		opts.Budget = b
+	}
+}
+
+// WithLimiter sets the rate limiter of retry attempts. The limiter is waited after the delay right before every retry,
+// but not the first attempt. The waiting time is included in the elapsed time of the next attempt.
+func WithLimiter(l Limiter) Option {
+	return func(opts *options) {
+		opts.Limiter = l
+	}
+}
+
+// WithAttemptTimeout sets the timeout of every attempt. Every operation call gets a child context with its own deadline.
+// An attempt that returned context.DeadlineExceeded after its deadline is retried as long as the parent context
+// isn't done, its error wraps ErrAttemptTimeout. Other errors, including permanent ones, are classified as usual.
+func WithAttemptTimeout(d time.Duration) Option {
+	return func(opts *options) {
+		opts.AttemptTimeout = d
+	}
+}
+
+// WithAttemptTimeoutFraction sets the timeout of every attempt as the fraction of the time remaining
+// until the context deadline, for example 0.5 gives every attempt half of the remaining time.
+// The fraction is ignored if the context has no deadline.
+// If WithAttemptTimeout is also set, the shorter timeout is used.
+func WithAttemptTimeoutFraction(f float64) Option {
+	return func(opts *options) {
+		opts.AttemptTimeoutFraction = f
+	}
+}
+
+// RetryAfterMode is a mode of using delays hinted by errors, see RetryAfter.
+type RetryAfterMode int
+
+const (
+	// RetryAfterReplace replaces the strategy delay with the hinted delay.
+	RetryAfterReplace RetryAfterMode = iota
+	// RetryAfterLonger uses the longer of the hinted and the strategy delays.
+	RetryAfterLonger
+	// RetryAfterIgnore ignores hinted delays.
+	RetryAfterIgnore
+)
+
+// WithRetryAfterMode sets the mode of using delays hinted by errors, RetryAfterReplace by default.
+func WithRetryAfterMode(m RetryAfterMode) Option {
+	return func(opts *options) {
+		opts.RetryAfterMode = m
+	}
+}
+
+// WithMaxRetryAfter caps delays hinted by errors.
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(opts *options) {
+		opts.MaxRetryAfter = d
+	}
+}
+
+func (opts *options) stop(err error) bool {
+	if anyMatch(opts.StopIf, err) {
+		return true
+	}
+	if len(opts.RetryIf) > 0 {
+		return !anyMatch(opts.RetryIf, err)
+	}
+	return notRetryable(err)
+}
+
+// report reports the attempt result to the breaker.
+// Canceled attempts and, unless the breaker classifies errors itself, errors that aren't retried are ignored,
+// so that the callers' mistakes and cancellations don't open the breaker shared with others.
+func (opts *options) report(ctx context.Context, err error) {
+	b := opts.Breaker
+	var perm PermanentError
+	switch {
+	case err == nil:
+		b.Success()
+	case ctx.Err() != nil || errors.Is(err, context.Canceled):
+		b.Ignore()
+	case b.IsFailure != nil:
+		if b.IsFailure(err) {
+			b.Failure()
+		} else {
+			b.Ignore()
+		}
+	case errors.As(err, &perm) || opts.stop(err):
+		b.Ignore()
+	default:
+		b.Failure()
+	}
+}
+
+// wrapMaxElapsedTime wraps the strategy with the max elapsed time stoppers that use the clock.
+func (opts *options) wrapMaxElapsedTime(clock Clock) {
+	for _, d := range opts.MaxElapsedTime {
+		opts.Strategy = MaxElapsedTimeWrapper{MaxElapsedTime: d, Clock: clock}.Wrap(opts.Strategy)
+	}
+}
+
+// withTimeout returns the context canceled after the timeout if it's set.
+func (opts *options) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	switch {
+	case opts.Timeout <= 0:
+		return ctx, func() {}
+	case opts.TimeoutCause != nil:
+		return context.WithTimeoutCause(ctx, opts.Timeout, opts.TimeoutCause)
+	default:
+		return context.WithTimeout(ctx, opts.Timeout)
+	}
+}
+
+func (opts *options) attemptTimeout(ctx context.Context) time.Duration {
+	d := opts.AttemptTimeout
+	if deadline, ok := ctx.Deadline(); ok && opts.AttemptTimeoutFraction > 0 {
+		if f := time.Duration(float64(time.Until(deadline)) * opts.AttemptTimeoutFraction); d <= 0 || f < d {
+			d = f
+		}
+	}
+	return d
+}
+
+// call calls the operation with the attempt timeout and reports whether the attempt timed out.
+// The attempt is timed out if the operation returned context.DeadlineExceeded after the attempt deadline,
+// other errors, including permanent ones, are classified as usual.
+func call[T any](ctx context.Context, timeout time.Duration, operation func(ctx context.Context) (T, error)) (T, bool, error) {
+	if timeout <= 0 {
+		result, err := operation(ctx)
+		return result, false, err
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	result, err := operation(attemptCtx)
+	var perm PermanentError
+	if err != nil && errors.Is(err, context.DeadlineExceeded) && !errors.As(err, &perm) &&
+		errors.Is(attemptCtx.Err(), context.DeadlineExceeded) && ctx.Err() == nil {
+		return result, true, fmt.Errorf("%w: %w", ErrAttemptTimeout, err)
+	}
+	return result, false, err
+}
+
+func (opts *options) retryAfter(err error, delay time.Duration) time.Duration {
+	var hint DelayHinter
+	if opts.RetryAfterMode == RetryAfterIgnore || !errors.As(err, &hint) {
+		return delay
+	}
+	d := hint.RetryDelay()
+	if d < 0 {
+		return delay
+	}
+	if opts.MaxRetryAfter > 0 && d > opts.MaxRetryAfter {
+		d = opts.MaxRetryAfter
+	}
+	if opts.RetryAfterMode == RetryAfterLonger && delay > d {
+		return delay
+	}
+	return d
+}
+
 // DoR retries the operation with result and specified strategy.
-// To stop the retry, the operation must return a permanent error, see Permanent(err).
+// To stop the retry, the operation must return a permanent error, see Permanent(err),
+// or the error must be classified as not retryable, see WithRetryIf and WithStopIf.
 func DoR[T any](ctx context.Context, strategy Strategy, operation func(ctx context.Context) (T, error), o ...Option) (result T, err error) {
 	opts := options{Strategy: strategy}
 
@@ -70,47 +310,121 @@ func DoR[T any](ctx context.Context, strategy Strategy, operation func(ctx conte
 		opt(&opts)
 	}
 
-	if opts.Timeout > 0 {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
-		defer cancel()
-	}
+	clock := clockOrDefault(opts.Clock)
+	opts.wrapMaxElapsedTime(clock)
 
-	start := time.Now()
+	ctx, cancel := opts.withTimeout(ctx)
+	defer cancel()
+
+	start := clock.Now()
 	retrying := 1
 	var delay time.Duration
+	var history attemptHistory
+	fail := func(ctxErr, reason error, msg string, lastDelay time.Duration) (T, error) {
+		var cause error
+		if ctxErr != nil {
+			cause = context.Cause(ctx)
+		}
+		return ptr.Zero[T](), newError(err, ctxErr, cause, reason, msg, retrying, lastDelay, clock.Now().Sub(start), history)
+	}
 
-	next := opts.Strategy.Iterator()
+	if len(opts.Hooks) > 0 {
+		defer func() {
+			info := AttemptInfo{Number: retrying, Elapsed: clock.Now().Sub(start), Err: err}
+			if err != nil {
+				opts.Hooks.OnGiveUp(ctx, info)
+			} else {
+				opts.Hooks.OnSuccess(ctx, info)
+			}
+		}()
+	}
+
+	next := &peekIterator{next: opts.Strategy.Iterator()}
+	var prevErr error
 	for {
 		if ctx.Err() != nil {
-			return ptr.Zero[T](), newError(err, ctx.Err(), "", retrying, delay, time.Since(start))
+			return fail(ctx.Err(), nil, "", delay)
 		}
 
-		result, err = operation(ctx)
+		if opts.Breaker != nil {
+			if bErr := opts.Breaker.Allow(); bErr != nil {
+				return fail(nil, bErr, bErr.Error(), delay)
+			}
+		}
+
+		var timedOut bool
+		attemptStart := clock.Now()
+		info := AttemptInfo{Number: retrying, Start: attemptStart, Elapsed: attemptStart.Sub(start)}
+		attemptCtx := withAttempt(ctx, Attempt{Number: retrying, Elapsed: info.Elapsed, PrevErr: prevErr, more: next.more})
+		attemptCtx = opts.Hooks.OnAttemptStart(attemptCtx, info)
+		result, timedOut, err = call(attemptCtx, opts.attemptTimeout(ctx), operation)
+		prevErr = err
+		attemptEnd := clock.Now()
+		info.Duration, info.Elapsed, info.Err = attemptEnd.Sub(attemptStart), attemptEnd.Sub(start), err
+		opts.Hooks.OnAttemptEnd(attemptCtx, info)
+		if opts.Breaker != nil {
+			opts.report(ctx, err)
+		}
 		if err == nil {
+			if opts.Budget != nil {
+				opts.Budget.Deposit()
+			}
 			return result, nil
 		}
-		var perm PermanentError
-		if ok := errors.As(err, &perm); ok {
-			return ptr.Zero[T](), perm
+		history.add(AttemptRecord{Number: retrying, Start: attemptStart, Duration: info.Duration, Err: err})
+		if !timedOut {
+			var perm PermanentError
+			if ok := errors.As(err, &perm); ok {
+				if opts.WrapPermanent {
+					err = perm.Err
+					return fail(nil, ErrPermanent, ErrPermanent.Error(), delay)
+				}
+				return ptr.Zero[T](), perm
+			}
+			if opts.stop(err) {
+				if opts.WrapPermanent {
+					return fail(nil, ErrPermanent, ErrPermanent.Error(), delay)
+				}
+				return ptr.Zero[T](), err
+			}
 		}
 
 		var nErr error
 		prevDelay := delay
-		delay, nErr = next()
-		elapsed := time.Since(start)
+		delay, nErr = next.take()
 		if delay == StopDelay {
-			return ptr.Zero[T](), newError(err, ctx.Err(), nErr.Error(), retrying, prevDelay, elapsed)
+			if nErr == nil {
+				nErr = ErrStopped
+			}
+			return fail(ctx.Err(), nErr, nErr.Error(), prevDelay)
+		}
+		delay = opts.retryAfter(err, delay)
+		if opts.Budget != nil && !opts.Budget.Withdraw() {
+			return fail(nil, ErrBudgetExhausted, ErrBudgetExhausted.Error(), prevDelay)
 		}
+		history.last().Delay = delay
 
+		info.Elapsed, info.Delay = clock.Now().Sub(start), delay
+		opts.Hooks.OnRetry(ctx, info)
 		if opts.Notify != nil {
-			opts.Notify(err, delay, retrying, elapsed)
+			opts.Notify(err, delay, retrying, info.Elapsed)
 		}
 
+		timer := clock.NewTimer(delay)
 		select {
 		case <-ctx.Done():
-			return ptr.Zero[T](), newError(err, ctx.Err(), "", retrying, delay, elapsed)
-		case <-time.After(delay):
+			timer.Stop()
+			return fail(ctx.Err(), nil, "", delay)
+		case <-timer.C():
+		}
+
+		if opts.Limiter != nil {
+			if lErr := opts.Limiter.Wait(ctx); lErr != nil {
+				if ctx.Err() != nil {
+					return fail(ctx.Err(), nil, "", delay)
+				}
+				return fail(nil, lErr, lErr.Error(), delay)
+			}
 		}
 
 		retrying++
@@ -2,8 +2,11 @@ package retry
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
+	"strings"
 	"testing"
 	"time"
 )
@@ -110,6 +113,26 @@ func TestRetryWithResult_MaxElapsedTime(t *testing.T) {
 	}
 }
 
+func TestDoE_MaxElapsedTimeStacked(t *testing.T) {
+	t.Parallel()
+
+	for _, o := range [][]Option{
+		{WithMaxElapsedTime(5 * time.Second)},
+		{WithMaxElapsedTime(time.Hour), WithMaxElapsedTime(5 * time.Second)},
+	} {
+		err := DoE(context.Background(), Constant(time.Second), func(ctx context.Context) error {
+			return errors.New("error")
+		}, time.Hour, append(o, WithClock(NewAutoFakeClock(time.Now())))...)
+		e := As(err)
+		if e == nil {
+			t.Fatalf("expected retry error, got: %v", err)
+		}
+		if e.ElapsedTime != 6*time.Second || !errors.Is(err, ErrMaxElapsedTime) {
+			t.Errorf("elapsed time want: %s, got: %s", 6*time.Second, e.ElapsedTime)
+		}
+	}
+}
+
 func TestRetry(t *testing.T) {
 	t.Parallel()
 
@@ -205,3 +228,517 @@ func TestUnwrap(t *testing.T) {
 		t.Errorf("As() = %v, want %v", got, err)
 	}
 }
+
+func TestDo_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		hint time.Duration
+		opts []Option
+		want time.Duration
+	}{
+		{name: "replace", hint: time.Minute, want: time.Minute},
+		{name: "replace shorter", hint: time.Millisecond, want: time.Millisecond},
+		{name: "capped", hint: time.Hour, opts: []Option{WithMaxRetryAfter(time.Minute)}, want: time.Minute},
+		{name: "longer hint", hint: time.Minute, opts: []Option{WithRetryAfterMode(RetryAfterLonger)}, want: time.Minute},
+		{name: "longer strategy", hint: time.Millisecond, opts: []Option{WithRetryAfterMode(RetryAfterLonger)}, want: time.Second},
+		{name: "ignore", hint: time.Minute, opts: []Option{WithRetryAfterMode(RetryAfterIgnore)}, want: time.Second},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var notified []time.Duration
+			opts := append([]Option{
+				WithClock(NewAutoFakeClock(time.Now())),
+				WithMaxRetries(2),
+				WithNotify(func(err error, delay time.Duration, try int, elapsed time.Duration) {
+					notified = append(notified, delay)
+				}),
+			}, tt.opts...)
+			err := Do(context.Background(), Constant(time.Second), func(ctx context.Context) error {
+				return RetryAfter(errors.New("throttled"), tt.hint)
+			}, opts...)
+
+			for _, d := range notified {
+				if d != tt.want {
+					t.Errorf("notified delay want: %s, got: %s", tt.want, d)
+				}
+			}
+			if e := As(err); e == nil || e.LastDelay != tt.want {
+				t.Errorf("last delay want: %s, got: %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDo_AttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	var attemptErrs []error
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		if count < 3 {
+			// Hangs until the attempt timeout.
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("expected attempt deadline")
+		}
+		return nil
+	}, WithAttemptTimeout(10*time.Millisecond), WithNotify(func(err error, delay time.Duration, try int, elapsed time.Duration) {
+		attemptErrs = append(attemptErrs, err)
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if count != 3 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 3)
+	}
+	for _, err := range attemptErrs {
+		if !errors.Is(err, ErrAttemptTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("unexpected attempt error: %v", err)
+		}
+	}
+}
+
+func TestDo_AttemptTimeoutClassified(t *testing.T) {
+	t.Parallel()
+
+	errBadRequest := errors.New("bad request")
+	tests := []struct {
+		name string
+		err  func(ctx context.Context) error
+		opts []Option
+		want error
+	}{
+		{name: "Permanent", err: func(ctx context.Context) error { return Permanent(ctx.Err()) }, want: PermanentError{Err: context.DeadlineExceeded}},
+		{name: "StopIf", err: func(ctx context.Context) error { return errBadRequest }, opts: []Option{WithStopIf(StopOn(errBadRequest))}, want: errBadRequest},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			count := 0
+			err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+				count++
+				<-ctx.Done()
+				return tt.err(ctx)
+			}, append(tt.opts, WithAttemptTimeout(10*time.Millisecond))...)
+			if err != tt.want {
+				t.Errorf("want: %v, got: %v", tt.want, err)
+			}
+			if count != 1 {
+				t.Errorf("unexpected count of retries: %d, expected: %d", count, 1)
+			}
+		})
+	}
+}
+
+func TestDo_AttemptTimeoutFraction(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Hour)
+	defer cancel()
+
+	err := Do(ctx, Zero(), func(ctx context.Context) error {
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			return Permanent(errors.New("expected attempt deadline"))
+		}
+		if d := time.Until(deadline); d > 31*time.Minute || d < 29*time.Minute {
+			return Permanent(fmt.Errorf("unexpected attempt timeout: %s", d))
+		}
+		return nil
+	}, WithAttemptTimeoutFraction(0.5))
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+}
+
+func TestDo_AttemptTimeoutParentDone(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithAttemptTimeout(time.Hour), WithTimeout(10*time.Millisecond))
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, ErrAttemptTimeout) {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 1)
+	}
+}
+
+func TestError_Attempts(t *testing.T) {
+	t.Parallel()
+
+	errTimeout := errors.New("timeout")
+	errUnavailable := errors.New("unavailable")
+	errs := []error{errTimeout, errUnavailable, errUnavailable}
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewAutoFakeClock(start)
+
+	count := 0
+	err := Do(context.Background(), Delays{time.Second, 2 * time.Second}, func(ctx context.Context) error {
+		count++
+		return errs[count-1]
+	}, WithClock(clock))
+
+	e := As(err)
+	if e == nil {
+		t.Fatalf("expected retry error, got: %v", err)
+	}
+	want := []AttemptRecord{
+		{Number: 1, Start: start, Delay: time.Second, Err: errTimeout},
+		{Number: 2, Start: start.Add(time.Second), Delay: 2 * time.Second, Err: errUnavailable},
+		{Number: 3, Start: start.Add(3 * time.Second), Err: errUnavailable},
+	}
+	attempts := e.Attempts()
+	if len(attempts) != len(want) {
+		t.Fatalf("attempts want: %v, got: %v", want, attempts)
+	}
+	for i := range want {
+		if attempts[i] != want[i] {
+			t.Errorf("attempt %d want: %v, got: %v", i+1, want[i], attempts[i])
+		}
+	}
+
+	if !errors.Is(err, errTimeout) {
+		t.Errorf("expected %s in %s", errTimeout, err)
+	}
+	if Unwrap(err) != errUnavailable {
+		t.Errorf("Unwrap() = %v, want %v", Unwrap(err), errUnavailable)
+	}
+
+	got := fmt.Sprintf("%+v", err)
+	for _, line := range []string{
+		err.Error(),
+		"attempt 1 at 2020-01-01T00:00:00Z, duration: 0s, delay: 1s: timeout",
+		"attempt 2 at 2020-01-01T00:00:01Z, duration: 0s, delay: 2s: unavailable",
+		"attempt 3 at 2020-01-01T00:00:03Z, duration: 0s, delay: 0s: unavailable",
+	} {
+		if !strings.Contains(got, line) {
+			t.Errorf("expected %q in %q", line, got)
+		}
+	}
+	if got := fmt.Sprintf("%v", err); got != err.Error() {
+		t.Errorf("%%v = %q, want %q", got, err.Error())
+	}
+}
+
+type customStop struct{}
+
+func (customStop) Iterator() Iterator {
+	return func() (time.Duration, error) { return StopDelay, errCustomStop }
+}
+
+var errCustomStop = errors.New("custom stop")
+
+func TestDo_Reason(t *testing.T) {
+	t.Parallel()
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	clock := WithClock(NewAutoFakeClock(time.Now()))
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		strategy Strategy
+		opts     []Option
+		want     error
+	}{
+		{name: "MaxRetries", ctx: context.Background(), strategy: Zero(), opts: []Option{WithMaxRetries(2)}, want: ErrMaxRetries},
+		{name: "MaxElapsedTime", ctx: context.Background(), strategy: Constant(time.Second), opts: []Option{clock, WithMaxElapsedTime(time.Minute)}, want: ErrMaxElapsedTime},
+		{name: "DelaysSpent", ctx: context.Background(), strategy: Delays{0}, want: ErrDelaysSpent},
+		{name: "Stopped", ctx: context.Background(), strategy: Stop(), want: ErrStopped},
+		{name: "Custom", ctx: context.Background(), strategy: customStop{}, want: errCustomStop},
+		{name: "Canceled", ctx: canceled, strategy: Zero(), want: ErrCanceled},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := Do(tt.ctx, tt.strategy, func(ctx context.Context) error {
+				return errors.New("error")
+			}, tt.opts...)
+			e := As(err)
+			if e == nil {
+				t.Fatalf("expected retry error, got: %v", err)
+			}
+			if !errors.Is(e.Reason, tt.want) || !errors.Is(err, tt.want) {
+				t.Errorf("reason want: %v, got: %v", tt.want, e.Reason)
+			}
+		})
+	}
+}
+
+func TestDo_WrapPermanent(t *testing.T) {
+	t.Parallel()
+
+	want := errors.New("permanent")
+	stop := errors.New("stop")
+	tests := []struct {
+		name string
+		err  error
+		opts []Option
+		want error
+	}{
+		{name: "Permanent", err: Permanent(want), want: want},
+		{name: "StopIf", err: stop, opts: []Option{WithStopIf(StopOn(stop))}, want: stop},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			count := 0
+			err := Do(context.Background(), Constant(time.Second), func(ctx context.Context) error {
+				count++
+				if count < 3 {
+					return errors.New("error")
+				}
+				return tt.err
+			}, append(tt.opts, WithClock(NewAutoFakeClock(time.Now())), WithWrapPermanent())...)
+			e := As(err)
+			if e == nil {
+				t.Fatalf("expected retry error, got: %v", err)
+			}
+			if e.Retries != 3 || e.LastDelay != time.Second || e.ElapsedTime != 2*time.Second {
+				t.Errorf("unexpected statistics: %+v", e)
+			}
+			if e.Reason != ErrPermanent || !errors.Is(err, ErrPermanent) {
+				t.Errorf("reason want: %v, got: %v", ErrPermanent, e.Reason)
+			}
+			if Unwrap(err) != tt.want || !errors.Is(err, tt.want) {
+				t.Errorf("want: %v, got: %v", tt.want, Unwrap(err))
+			}
+		})
+	}
+}
+
+func TestError_Encoding(t *testing.T) {
+	t.Parallel()
+
+	clock := NewAutoFakeClock(time.Now())
+	err := Do(context.Background(), Constant(time.Second), func(ctx context.Context) error {
+		return errors.New("unavailable")
+	}, WithClock(clock), WithMaxRetries(2))
+	if As(err) == nil {
+		t.Fatalf("expected retry error, got: %v", err)
+	}
+
+	t.Run("LogValue", func(t *testing.T) {
+		b := &strings.Builder{}
+		slog.New(slog.NewJSONHandler(b, nil)).Error("failed", "err", err)
+		want := `"err":{"retries":3,"elapsed_ms":2000,"last_delay_ms":1000,"reason":"maximum retries elapsed: 2","error":"unavailable"}`
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("expected %q in %q", want, b.String())
+		}
+	})
+
+	t.Run("MarshalJSON", func(t *testing.T) {
+		b, jErr := json.Marshal(err)
+		if jErr != nil {
+			t.Fatalf("unexpected error: %s", jErr)
+		}
+		want := `{"retries":3,"elapsed_ms":2000,"last_delay_ms":1000,"reason":"maximum retries elapsed: 2","error":"unavailable"}`
+		if string(b) != want {
+			t.Errorf("got: %s, want: %s", b, want)
+		}
+	})
+
+	t.Run("Format", func(t *testing.T) {
+		want := "\n\tretries: 3, elapsed: 2s, last delay: 1s, reason: maximum retries elapsed: 2\n\tattempt 1"
+		if got := fmt.Sprintf("%+v", err); !strings.Contains(got, want) {
+			t.Errorf("expected %q in %q", want, got)
+		}
+		msg := err.Error()
+		for format, want := range map[string]string{
+			"%s":   msg,
+			"%v":   msg,
+			"%q":   fmt.Sprintf("%q", msg),
+			"%x":   fmt.Sprintf("%x", msg),
+			"%.7s": msg[:7],
+			"%d":   "%!d(string=" + msg + ")",
+		} {
+			if got := fmt.Sprintf(format, err); got != want {
+				t.Errorf("%s: got: %q, want: %q", format, got, want)
+			}
+		}
+	})
+}
+
+func TestDo_Cause(t *testing.T) {
+	t.Parallel()
+
+	errUpstream := errors.New("upstream canceled")
+	errBudget := errors.New("retry budget exceeded")
+
+	t.Run("Parent", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancelCause(context.Background())
+		err := Do(ctx, Constant(time.Hour), func(ctx context.Context) error {
+			cancel(errUpstream)
+			return errors.New("error")
+		})
+		e := As(err)
+		if e == nil || e.Cause != errUpstream || !errors.Is(err, errUpstream) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		t.Parallel()
+
+		err := Do(context.Background(), Constant(time.Hour), func(ctx context.Context) error {
+			return errors.New("error")
+		}, WithTimeout(time.Millisecond, errBudget))
+		e := As(err)
+		if e == nil || e.Cause != errBudget || !errors.Is(err, errBudget) {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if errors.Is(err, errUpstream) {
+			t.Errorf("unexpected cause: %v", err)
+		}
+	})
+
+	t.Run("NoCause", func(t *testing.T) {
+		t.Parallel()
+
+		err := Do(context.Background(), Constant(time.Hour), func(ctx context.Context) error {
+			return errors.New("error")
+		}, WithTimeout(time.Millisecond))
+		if e := As(err); e == nil || e.Cause != context.DeadlineExceeded {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Uncomparable", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancelCause(context.Background())
+		err := Do(ctx, Zero(), func(ctx context.Context) error {
+			cancel(sliceErr{parts: []string{"canceled"}})
+			return sliceErr{parts: []string{"error"}}
+		})
+		if e := As(err); e == nil || errors.Is(err, errUpstream) || len(e.Unwrap()) != 3 {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := Do(ctx, Zero(), func(ctx context.Context) error {
+			return errors.New("error")
+		})
+		if e := As(err); e == nil || e.Cause != context.Canceled || len(e.Unwrap()) != 2 {
+			t.Errorf("unexpected error: %v", err)
+		}
+	})
+}
+
+func TestError_AttemptsLimit(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		return fmt.Errorf("error %d", count)
+	}, WithMaxRetries(100))
+	e := As(err)
+	if e == nil {
+		t.Fatalf("expected retry error, got: %v", err)
+	}
+	attempts := e.Attempts()
+	if len(attempts) != 2*maxAttemptRecords || e.DroppedAttempts() != 81 {
+		t.Fatalf("unexpected history: %d attempts, %d dropped", len(attempts), e.DroppedAttempts())
+	}
+	for i, a := range attempts {
+		want := i + 1
+		if i >= maxAttemptRecords {
+			want = i + 82
+		}
+		if a.Number != want {
+			t.Errorf("attempt %d number want: %d, got: %d", i, want, a.Number)
+		}
+	}
+	if got := fmt.Sprintf("%+v", err); !strings.Contains(got, "\n\tattempt 10 at ") ||
+		!strings.Contains(got, "\n\t... 81 attempts dropped\n\tattempt 92 at ") {
+		t.Errorf("unexpected history: %s", got)
+	}
+}
+
+func TestError_UnwrapControl(t *testing.T) {
+	t.Parallel()
+
+	errLast := errors.New("last")
+	errThrottled := errors.New("throttled")
+	count := 0
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		count++
+		switch count {
+		case 1:
+			return RetryAfter(errThrottled, time.Millisecond)
+		case 2:
+			return errors.New("unavailable")
+		default:
+			return Permanent(errLast)
+		}
+	}, WithWrapPermanent())
+	e := As(err)
+	if e == nil {
+		t.Fatalf("expected retry error, got: %v", err)
+	}
+	var hint DelayHinter
+	if errors.As(err, &hint) {
+		t.Errorf("the delay hint of an earlier attempt must not be unwrapped: %v", hint)
+	}
+	if !errors.Is(err, errThrottled) {
+		t.Errorf("expected %s in %s", errThrottled, err)
+	}
+	var perm PermanentError
+	if errors.As(err, &perm) {
+		t.Errorf("the permanent error must not be unwrapped: %v", perm)
+	}
+	if got := e.Attempts()[2].Err; got != errLast {
+		t.Errorf("attempt error want: %v, got: %v", errLast, got)
+	}
+	if !errors.Is(err, errLast) {
+		t.Errorf("expected %s in %s", errLast, err)
+	}
+}
+
+type sliceErr struct {
+	parts []string
+}
+
+func (e sliceErr) Error() string { return strings.Join(e.parts, ", ") }
+
+func TestError_UnwrapUncomparable(t *testing.T) {
+	t.Parallel()
+
+	err := Do(context.Background(), Zero(), func(ctx context.Context) error {
+		return sliceErr{parts: []string{"a"}}
+	}, WithMaxRetries(2))
+	if errors.Is(err, context.Canceled) {
+		t.Errorf("unexpected canceled error: %s", err)
+	}
+	var target sliceErr
+	if !errors.As(err, &target) || len(As(err).Unwrap()) != 4 {
+		t.Errorf("unexpected unwrapped errors: %v", As(err).Unwrap())
+	}
+}
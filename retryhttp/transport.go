@@ -0,0 +1,258 @@
+// Package retryhttp provides retrying HTTP transport and client.
+package retryhttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gotidy/retry"
+)
+
+// maxDrain is the maximum count of bytes read from a failed response body before closing it,
+// so the connection can be reused.
+const maxDrain = 4096
+
+// StatusError is an error of a response with a retryable status code.
+type StatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("unexpected response status: %s", e.Status)
+}
+
+// Transport is an http.RoundTripper that retries requests with the strategy.
+//
+// Only requests with idempotent methods are retried by default, see IsIdempotent.
+// Requests with a body are retried only if Request.GetBody is set.
+// Network errors and responses with retryable status codes are retried, see IsNetworkError and IsRetryableStatus,
+// other errors of the base round tripper are permanent.
+// When retries are exhausted the last response is returned.
+//
+// Every attempt request gets the context of the attempt, so it carries the values set by the retrying,
+// such as retry.Attempt, and is canceled by retry.WithTimeout and retry.WithAttemptTimeout
+// until the response headers are received. After that the response body stays readable
+// until it's closed or the request context is done.
+type Transport struct {
+	// Base is the underlying round tripper, http.DefaultTransport by default.
+	Base http.RoundTripper
+	// Strategy is the retrying strategy.
+	Strategy retry.Strategy
+	// Options are retrying options.
+	Options []retry.Option
+	// Retryable reports whether the request may be retried, IsIdempotent by default.
+	Retryable func(req *http.Request) bool
+	// RetryStatus reports whether the response status code should be retried, IsRetryableStatus by default.
+	RetryStatus func(code int) bool
+	// AttemptTimeout is a timeout of every attempt including reading the response body,
+	// unlike retry.WithAttemptTimeout, which bounds the attempt until the response headers are received.
+	AttemptTimeout time.Duration
+}
+
+// NewTransport creates a retrying transport over the base transport.
+func NewTransport(base http.RoundTripper, strategy retry.Strategy, opts ...retry.Option) *Transport {
+	return &Transport{Base: base, Strategy: strategy, Options: opts}
+}
+
+// NewClient creates an HTTP client with the retrying transport over http.DefaultTransport.
+func NewClient(strategy retry.Strategy, opts ...retry.Option) *http.Client {
+	return &http.Client{Transport: NewTransport(nil, strategy, opts...)}
+}
+
+// IsIdempotent reports whether the request method is idempotent
+// or the request has an idempotency key header.
+func IsIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	_, ok := req.Header["Idempotency-Key"]
+	if !ok {
+		_, ok = req.Header["X-Idempotency-Key"]
+	}
+	return ok
+}
+
+// IsRetryableStatus reports whether the status code is 429, 502, 503 or 504.
+func IsRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// ParseRetryAfter parses the Retry-After header value in seconds or HTTP-date form.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if d := date.Sub(now); d > 0 {
+		return d, true
+	}
+	return 0, true
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base == nil {
+		return http.DefaultTransport
+	}
+	return t.Base
+}
+
+func (t *Transport) retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if t.Retryable != nil {
+		return t.Retryable(req)
+	}
+	return IsIdempotent(req)
+}
+
+// IsNetworkError reports whether the error of a round trip is a network failure that may be retried:
+// a net.Error, such as a timeout or a dial error, an unexpected EOF or a connection reset, refusal or abort.
+// Errors of invalid requests, unsupported schemes or TLS certificates aren't network failures.
+func IsNetworkError(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+func (t *Transport) retryStatus(code int) bool {
+	if t.RetryStatus != nil {
+		return t.RetryStatus(code)
+	}
+	return IsRetryableStatus(code)
+}
+
+// RoundTrip executes the request with retrying.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.retryable(req) {
+		return t.base().RoundTrip(req)
+	}
+
+	var last *http.Response
+	attempt := 0
+	resp, err := retry.DoR(req.Context(), t.Strategy, func(ctx context.Context) (*http.Response, error) {
+		if last != nil {
+			drain(last)
+			last = nil
+		}
+
+		attemptCtx, cancel, stopAttempt := t.attemptContext(req.Context(), ctx)
+
+		r := req
+		switch {
+		case attempt > 0 && req.GetBody != nil:
+			body, err := req.GetBody()
+			if err != nil {
+				cancel()
+				return nil, retry.Permanent(err)
+			}
+			r = req.Clone(attemptCtx)
+			r.Body = body
+		default:
+			r = req.WithContext(attemptCtx)
+		}
+		attempt++
+
+		resp, err := t.base().RoundTrip(r)
+		if !stopAttempt() {
+			// The attempt is done, the response body is already canceled.
+			cancel()
+			if err == nil {
+				drain(resp)
+				return nil, ctx.Err()
+			}
+			return nil, fmt.Errorf("%w: %w", err, ctx.Err())
+		}
+		if err != nil {
+			cancel()
+			if !IsNetworkError(err) && !errors.Is(err, context.Canceled) {
+				return nil, retry.Permanent(err)
+			}
+			return nil, err
+		}
+		resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+		if !t.retryStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		last = resp
+		err = &StatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+		if d, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+			return nil, retry.RetryAfter(err, d)
+		}
+		return nil, err
+	}, t.Options...)
+	if err != nil && last != nil {
+		if req.Context().Err() == nil {
+			return last, nil
+		}
+		drain(last)
+	}
+	return resp, err
+}
+
+// attemptContext returns the context of the attempt request that keeps the values of the attempt context ctx.
+// The context is canceled with ctx until stopAttempt is called, and with the request context reqCtx
+// and cancel after that.
+func (t *Transport) attemptContext(reqCtx, ctx context.Context) (attemptCtx context.Context, cancel context.CancelFunc, stopAttempt func() bool) {
+	attemptCtx, cancelCause := context.WithCancelCause(context.WithoutCancel(ctx))
+	stopRequest := context.AfterFunc(reqCtx, func() { cancelCause(context.Cause(reqCtx)) })
+	stopAttempt = context.AfterFunc(ctx, func() { cancelCause(context.Cause(ctx)) })
+	cancelTimeout := context.CancelFunc(func() {})
+	if t.AttemptTimeout > 0 {
+		attemptCtx, cancelTimeout = context.WithTimeout(attemptCtx, t.AttemptTimeout)
+	}
+	cancel = func() {
+		stopRequest()
+		stopAttempt()
+		cancelTimeout()
+		cancelCause(context.Canceled)
+	}
+	return attemptCtx, cancel, stopAttempt
+}
+
+// cancelBody cancels the attempt context when the body is closed.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxDrain))
+	_ = resp.Body.Close()
+}
@@ -0,0 +1,325 @@
+package retryhttp
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gotidy/retry"
+)
+
+func newServer(t *testing.T, failures int32, status int, header http.Header) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if count.Add(1) <= failures {
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte("failure"))
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &count
+}
+
+func testClock() retry.Option {
+	return retry.WithClock(retry.NewAutoFakeClock(time.Now()))
+}
+
+func TestTransport(t *testing.T) {
+	t.Parallel()
+
+	srv, count := newServer(t, 2, http.StatusServiceUnavailable, nil)
+	client := NewClient(retry.Constant(time.Second), testClock(), retry.WithMaxRetries(5))
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status want: %d, got: %d", http.StatusOK, resp.StatusCode)
+	}
+	if n := count.Load(); n != 3 {
+		t.Errorf("requests want: %d, got: %d", 3, n)
+	}
+}
+
+func TestTransport_Body(t *testing.T) {
+	t.Parallel()
+
+	srv, count := newServer(t, 2, http.StatusBadGateway, nil)
+	client := NewClient(retry.Zero(), retry.WithMaxRetries(5))
+
+	req, _ := http.NewRequest(http.MethodPut, srv.URL, strings.NewReader("payload"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if string(body) != "payload" {
+		t.Errorf("body want: %q, got: %q", "payload", body)
+	}
+	if n := count.Load(); n != 3 {
+		t.Errorf("requests want: %d, got: %d", 3, n)
+	}
+}
+
+func TestTransport_NotIdempotent(t *testing.T) {
+	t.Parallel()
+
+	srv, count := newServer(t, 2, http.StatusServiceUnavailable, nil)
+	client := NewClient(retry.Zero(), retry.WithMaxRetries(5))
+
+	resp, err := client.Post(srv.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("status want: %d, got: %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	if n := count.Load(); n != 1 {
+		t.Errorf("requests want: %d, got: %d", 1, n)
+	}
+}
+
+func TestTransport_Exhausted(t *testing.T) {
+	t.Parallel()
+
+	srv, count := newServer(t, 10, http.StatusTooManyRequests, nil)
+	client := NewClient(retry.Zero(), retry.WithMaxRetries(2))
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || string(body) != "failure" {
+		t.Errorf("unexpected response: %d %q", resp.StatusCode, body)
+	}
+	if n := count.Load(); n != 3 {
+		t.Errorf("requests want: %d, got: %d", 3, n)
+	}
+}
+
+func TestTransport_RetryAfter(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newServer(t, 1, http.StatusTooManyRequests, http.Header{"Retry-After": {"120"}})
+	var delays []time.Duration
+	client := NewClient(retry.Constant(time.Second), testClock(), retry.WithNotify(func(err error, delay time.Duration, try int, elapsed time.Duration) {
+		delays = append(delays, delay)
+	}))
+
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_ = resp.Body.Close()
+	if len(delays) != 1 || delays[0] != 2*time.Minute {
+		t.Errorf("delays want: %v, got: %v", []time.Duration{2 * time.Minute}, delays)
+	}
+}
+
+func TestTransport_NetworkError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+
+	count := 0
+	client := NewClient(retry.Zero(), retry.WithMaxRetries(2), retry.WithNotify(func(err error, delay time.Duration, try int, elapsed time.Duration) {
+		count++
+	}))
+	_, err := client.Get(srv.URL)
+	if err == nil {
+		t.Fatal("expected error but got nil")
+	}
+	if count != 2 {
+		t.Errorf("retries want: %d, got: %d", 2, count)
+	}
+	var e *retry.Error
+	if !errors.As(err, &e) {
+		t.Errorf("expected retry error, got: %v", err)
+	}
+}
+
+func TestTransport_NotNetworkError(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	t.Cleanup(srv.Close)
+
+	for _, url := range []string{"ftp://" + srv.Listener.Addr().String(), srv.URL} {
+		count := 0
+		base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+			count++
+			return http.DefaultTransport.RoundTrip(r)
+		})
+		client := &http.Client{Transport: NewTransport(base, retry.Zero(), retry.WithMaxRetries(2))}
+		resp, err := client.Get(url)
+		if err == nil {
+			_ = resp.Body.Close()
+			t.Fatalf("%s: expected error but got nil", url)
+		}
+		if count != 1 {
+			t.Errorf("%s: attempts want: %d, got: %d, error: %s", url, 1, count, err)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{value: "", ok: false},
+		{value: "5", want: 5 * time.Second, ok: true},
+		{value: "-5", ok: false},
+		{value: now.Add(time.Minute).Format(http.TimeFormat), want: time.Minute, ok: true},
+		{value: now.Add(-time.Minute).Format(http.TimeFormat), want: 0, ok: true},
+		{value: "soon", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRetryAfter(tt.value, now)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("ParseRetryAfter(%q) = %s, %v, want: %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestTransport_AttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	transport := NewTransport(nil, retry.Zero(), retry.WithMaxRetries(2))
+	transport.AttemptTimeout = 50 * time.Millisecond
+	client := &http.Client{Transport: transport}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil || string(body) != "ok" {
+		t.Errorf("unexpected body: %q, %v", body, err)
+	}
+	if n := count.Load(); n != 2 {
+		t.Errorf("requests want: %d, got: %d", 2, n)
+	}
+}
+
+func TestTransport_RetryTimeout(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count.Add(1)
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewClient(retry.Zero(), retry.WithTimeout(50*time.Millisecond), retry.WithMaxRetries(2))
+	start := time.Now()
+	resp, err := client.Get(srv.URL)
+	if err == nil {
+		_ = resp.Body.Close()
+		t.Fatal("expected error but got nil")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("request isn't canceled by the timeout, elapsed: %s", elapsed)
+	}
+}
+
+func TestTransport_RetryAttemptTimeout(t *testing.T) {
+	t.Parallel()
+
+	var count atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if count.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		_, _ = w.Write([]byte("ok"))
+	}))
+	t.Cleanup(srv.Close)
+
+	client := NewClient(retry.Zero(), retry.WithAttemptTimeout(50*time.Millisecond), retry.WithMaxRetries(2))
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil || string(body) != "ok" {
+		t.Errorf("unexpected body: %q, %v", body, err)
+	}
+	if n := count.Load(); n != 2 {
+		t.Errorf("requests want: %d, got: %d", 2, n)
+	}
+}
+
+type roundTripperFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransport_AttemptContext(t *testing.T) {
+	t.Parallel()
+
+	srv, _ := newServer(t, 1, http.StatusServiceUnavailable, nil)
+	var numbers []int
+	base := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		a, _ := retry.AttemptFromContext(r.Context())
+		numbers = append(numbers, a.Number)
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	client := &http.Client{Transport: NewTransport(base, retry.Zero(), retry.WithMaxRetries(5))}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	_ = resp.Body.Close()
+	if want := []int{1, 2}; !reflect.DeepEqual(numbers, want) {
+		t.Errorf("attempt numbers want: %v, got: %v", want, numbers)
+	}
+}
@@ -0,0 +1,289 @@
+// Package retrymetrics collects retrying metrics and exports them with expvar and in the Prometheus text format.
+package retrymetrics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gotidy/retry"
+)
+
+// DefaultBuckets are the default upper bounds of the delay and elapsed time histograms in seconds.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// Reasons of giving up. The reasons of the retry error that aren't listed here are reported as ReasonOther,
+// so the count of label values is bounded.
+const (
+	ReasonPermanent       = "permanent"
+	ReasonCanceled        = "canceled"
+	ReasonStopped         = "stopped"
+	ReasonMaxRetries      = "max_retries"
+	ReasonMaxElapsedTime  = "max_elapsed_time"
+	ReasonDelaysSpent     = "delays_spent"
+	ReasonCircuitOpen     = "circuit_open"
+	ReasonBudgetExhausted = "budget_exhausted"
+	ReasonOther           = "other"
+)
+
+// Collector collects the counts of attempts, retries, successes and give-ups by reason,
+// and the histograms of retry delays and total elapsed time, labelled by the operation name.
+//
+// The collector is safe for concurrent use and must not be copied after first use.
+type Collector struct {
+	// Buckets are upper bounds of the histograms in seconds, DefaultBuckets by default.
+	// They must be sorted and must not be changed after first use.
+	Buckets []float64
+
+	mu  sync.Mutex
+	ops map[string]*metrics
+}
+
+// NewCollector creates the collector.
+func NewCollector() *Collector {
+	return &Collector{}
+}
+
+// Option returns the retry option that collects metrics of the operation.
+func (c *Collector) Option(operation string) retry.Option {
+	return retry.WithHooks(c.Hooks(operation))
+}
+
+// Hooks returns the retry hooks that collect metrics of the operation.
+func (c *Collector) Hooks(operation string) retry.Hooks {
+	return hooks{collector: c, operation: operation}
+}
+
+// WritePrometheus writes the metrics in the Prometheus text exposition format.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	names := c.operations()
+	b := &strings.Builder{}
+	writeCounter := func(name, help string, value func(m *metrics) uint64) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", name, help, name)
+		for _, op := range names {
+			fmt.Fprintf(b, "%s{operation=%s} %d\n", name, quote(op), value(c.ops[op]))
+		}
+	}
+	writeCounter("retry_attempts_total", "Total count of operation attempts.", func(m *metrics) uint64 { return m.attempts })
+	writeCounter("retry_retries_total", "Total count of retries.", func(m *metrics) uint64 { return m.retries })
+	writeCounter("retry_successes_total", "Total count of successful operations.", func(m *metrics) uint64 { return m.successes })
+
+	b.WriteString("# HELP retry_give_ups_total Total count of give-ups by reason.\n# TYPE retry_give_ups_total counter\n")
+	for _, op := range names {
+		m := c.ops[op]
+		for _, reason := range sortedKeys(m.giveUps) {
+			fmt.Fprintf(b, "retry_give_ups_total{operation=%s,reason=%s} %d\n", quote(op), quote(reason), m.giveUps[reason])
+		}
+	}
+
+	writeHistogram := func(name, help string, value func(m *metrics) *histogram) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+		for _, op := range names {
+			h := value(c.ops[op])
+			var cumulative uint64
+			for i, le := range h.buckets {
+				cumulative += h.counts[i]
+				fmt.Fprintf(b, "%s_bucket{operation=%s,le=%s} %d\n", name, quote(op), quote(formatFloat(le)), cumulative)
+			}
+			fmt.Fprintf(b, "%s_bucket{operation=%s,le=\"+Inf\"} %d\n", name, quote(op), h.count)
+			fmt.Fprintf(b, "%s_sum{operation=%s} %s\n", name, quote(op), formatFloat(h.sum))
+			fmt.Fprintf(b, "%s_count{operation=%s} %d\n", name, quote(op), h.count)
+		}
+	}
+	writeHistogram("retry_delay_seconds", "Delays before retries.", func(m *metrics) *histogram { return &m.delay })
+	writeHistogram("retry_elapsed_seconds", "Total elapsed time of retrying.", func(m *metrics) *histogram { return &m.elapsed })
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = c.WritePrometheus(w)
+}
+
+// Var returns the expvar variable with the metrics snapshot.
+func (c *Collector) Var() expvar.Var {
+	return expvar.Func(c.snapshot)
+}
+
+// Publish publishes the metrics with expvar under the name. Like expvar.Publish it panics if the name is already registered.
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, c.Var())
+}
+
+func (c *Collector) snapshot() any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	snapshot := make(map[string]any, len(c.ops))
+	for op, m := range c.ops {
+		giveUps := make(map[string]uint64, len(m.giveUps))
+		for reason, n := range m.giveUps {
+			giveUps[reason] = n
+		}
+		snapshot[op] = map[string]any{
+			"attempts":  m.attempts,
+			"retries":   m.retries,
+			"successes": m.successes,
+			"give_ups":  giveUps,
+			"delay":     m.delay.snapshot(),
+			"elapsed":   m.elapsed.snapshot(),
+		}
+	}
+	return snapshot
+}
+
+func (c *Collector) operations() []string {
+	names := make([]string, 0, len(c.ops))
+	for op := range c.ops {
+		names = append(names, op)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *Collector) update(operation string, f func(m *metrics)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.ops == nil {
+		c.ops = map[string]*metrics{}
+	}
+	m, ok := c.ops[operation]
+	if !ok {
+		buckets := c.Buckets
+		if buckets == nil {
+			buckets = DefaultBuckets
+		}
+		m = &metrics{giveUps: map[string]uint64{}, delay: newHistogram(buckets), elapsed: newHistogram(buckets)}
+		c.ops[operation] = m
+	}
+	f(m)
+}
+
+type metrics struct {
+	attempts  uint64
+	retries   uint64
+	successes uint64
+	giveUps   map[string]uint64
+	delay     histogram
+	elapsed   histogram
+}
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) histogram {
+	return histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) snapshot() map[string]any {
+	buckets := make(map[string]uint64, len(h.buckets))
+	var cumulative uint64
+	for i, le := range h.buckets {
+		cumulative += h.counts[i]
+		buckets[formatFloat(le)] = cumulative
+	}
+	return map[string]any{"count": h.count, "sum": h.sum, "buckets": buckets}
+}
+
+type hooks struct {
+	retry.NopHooks
+	collector *Collector
+	operation string
+}
+
+func (h hooks) OnAttemptStart(ctx context.Context, _ retry.AttemptInfo) context.Context {
+	h.collector.update(h.operation, func(m *metrics) { m.attempts++ })
+	return ctx
+}
+
+func (h hooks) OnRetry(_ context.Context, info retry.AttemptInfo) {
+	h.collector.update(h.operation, func(m *metrics) {
+		m.retries++
+		m.delay.observe(info.Delay.Seconds())
+	})
+}
+
+func (h hooks) OnGiveUp(_ context.Context, info retry.AttemptInfo) {
+	h.collector.update(h.operation, func(m *metrics) {
+		m.giveUps[Reason(info.Err)]++
+		m.elapsed.observe(info.Elapsed.Seconds())
+	})
+}
+
+func (h hooks) OnSuccess(_ context.Context, info retry.AttemptInfo) {
+	h.collector.update(h.operation, func(m *metrics) {
+		m.successes++
+		m.elapsed.observe(info.Elapsed.Seconds())
+	})
+}
+
+// Reason returns the reason of giving up with the error.
+// The errors that aren't *retry.Error are reported as ReasonPermanent, because DoR returns them as is
+// only for permanent errors and errors that aren't retried, see retry.WithStopIf and retry.WithRetryIf.
+func Reason(err error) string {
+	e := retry.As(err)
+	switch {
+	case e == nil, errors.Is(e.Reason, retry.ErrPermanent):
+		return ReasonPermanent
+	case errors.Is(e.Reason, retry.ErrCanceled):
+		return ReasonCanceled
+	case errors.Is(e.Reason, retry.ErrMaxRetries):
+		return ReasonMaxRetries
+	case errors.Is(e.Reason, retry.ErrMaxElapsedTime):
+		return ReasonMaxElapsedTime
+	case errors.Is(e.Reason, retry.ErrDelaysSpent):
+		return ReasonDelaysSpent
+	case errors.Is(e.Reason, retry.ErrCircuitOpen):
+		return ReasonCircuitOpen
+	case errors.Is(e.Reason, retry.ErrBudgetExhausted):
+		return ReasonBudgetExhausted
+	case e.Reason == nil, errors.Is(e.Reason, retry.ErrStopped):
+		return ReasonStopped
+	default:
+		return ReasonOther
+	}
+}
+
+func quote(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func sortedKeys(m map[string]uint64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,183 @@
+package retrymetrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gotidy/retry"
+)
+
+func collect(t *testing.T, c *Collector) {
+	t.Helper()
+
+	clock := retry.WithClock(retry.NewAutoFakeClock(time.Now()))
+	count := 0
+	err := retry.Do(context.Background(), retry.Constant(time.Second), func(ctx context.Context) error {
+		count++
+		if count == 3 {
+			return nil
+		}
+		return errors.New("error")
+	}, clock, c.Option("get"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	_ = retry.Do(context.Background(), retry.Constant(2*time.Second), func(ctx context.Context) error {
+		return errors.New("error")
+	}, clock, retry.WithMaxRetries(1), c.Option("put"))
+
+	_ = retry.Do(context.Background(), retry.Zero(), func(ctx context.Context) error {
+		return retry.Permanent(errors.New("error"))
+	}, clock, c.Option("put"))
+}
+
+func TestCollector_WritePrometheus(t *testing.T) {
+	t.Parallel()
+
+	c := NewCollector()
+	collect(t, c)
+
+	b := &strings.Builder{}
+	if err := c.WritePrometheus(b); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	got := b.String()
+	for _, line := range []string{
+		"# TYPE retry_attempts_total counter",
+		`retry_attempts_total{operation="get"} 3`,
+		`retry_attempts_total{operation="put"} 3`,
+		`retry_retries_total{operation="get"} 2`,
+		`retry_successes_total{operation="get"} 1`,
+		`retry_give_ups_total{operation="put",reason="permanent"} 1`,
+		`retry_give_ups_total{operation="put",reason="max_retries"} 1`,
+		"# TYPE retry_delay_seconds histogram",
+		`retry_delay_seconds_bucket{operation="get",le="0.5"} 0`,
+		`retry_delay_seconds_bucket{operation="get",le="1"} 2`,
+		`retry_delay_seconds_bucket{operation="get",le="+Inf"} 2`,
+		`retry_delay_seconds_sum{operation="get"} 2`,
+		`retry_delay_seconds_count{operation="get"} 2`,
+		`retry_elapsed_seconds_bucket{operation="put",le="2.5"} 2`,
+		`retry_elapsed_seconds_sum{operation="put"} 2`,
+	} {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("expected %q in:\n%s", line, got)
+		}
+	}
+}
+
+func TestCollector_ServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	c := NewCollector()
+	collect(t, c)
+
+	srv := httptest.NewServer(c)
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); !strings.HasPrefix(ct, "text/plain") {
+		t.Errorf("unexpected content type: %s", ct)
+	}
+	if !strings.Contains(string(body), `retry_attempts_total{operation="get"} 3`) {
+		t.Errorf("unexpected body:\n%s", body)
+	}
+}
+
+func TestCollector_Publish(t *testing.T) {
+	t.Parallel()
+
+	c := NewCollector()
+	collect(t, c)
+	c.Publish("retrymetrics_test")
+
+	var got map[string]struct {
+		Attempts uint64            `json:"attempts"`
+		GiveUps  map[string]uint64 `json:"give_ups"`
+		Delay    struct {
+			Count   uint64            `json:"count"`
+			Buckets map[string]uint64 `json:"buckets"`
+		} `json:"delay"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get("retrymetrics_test").String()), &got); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if got["get"].Attempts != 3 || got["get"].Delay.Count != 2 || got["get"].Delay.Buckets["1"] != 2 {
+		t.Errorf("unexpected snapshot: %+v", got)
+	}
+	if got["put"].GiveUps[ReasonPermanent] != 1 {
+		t.Errorf("unexpected snapshot: %+v", got)
+	}
+}
+
+func TestReason(t *testing.T) {
+	t.Parallel()
+
+	giveUp := func(strategy retry.Strategy, o ...retry.Option) error {
+		return retry.Do(context.Background(), strategy, func(ctx context.Context) error {
+			return errors.New("error")
+		}, o...)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	open := retry.NewCircuitBreaker(1, time.Hour)
+	_ = open.Allow()
+	open.Failure()
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "Permanent", err: retry.Permanent(errors.New("error")), want: ReasonPermanent},
+		{name: "Canceled", err: retry.Do(canceled, retry.Zero(), func(ctx context.Context) error { return nil }), want: ReasonCanceled},
+		{name: "MaxRetries", err: giveUp(retry.Zero(), retry.WithMaxRetries(1)), want: ReasonMaxRetries},
+		{name: "DelaysSpent", err: giveUp(retry.Delays{0}), want: ReasonDelaysSpent},
+		{name: "Stopped", err: giveUp(retry.Stop()), want: ReasonStopped},
+		{name: "CircuitOpen", err: giveUp(retry.Zero(), retry.WithBreaker(open)), want: ReasonCircuitOpen},
+		{name: "BudgetExhausted", err: giveUp(retry.Zero(), retry.WithBudget(retry.NewRetryBudget(0, 0))), want: ReasonBudgetExhausted},
+		{name: "NotRetryable", err: giveUp(retry.Zero(), retry.WithStopIf(func(error) bool { return true })), want: ReasonPermanent},
+		{name: "AttemptTimeout", err: retry.Do(context.Background(), retry.Zero(), func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, retry.WithAttemptTimeout(time.Millisecond), retry.WithMaxRetries(1)), want: ReasonMaxRetries},
+		{name: "Other", err: giveUp(stopWith{fmt.Errorf("custom stop %d", 42)}), want: ReasonOther},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := Reason(tt.err); got != tt.want {
+				t.Errorf("got: %s, want: %s, error: %v", got, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+type stopWith struct{ err error }
+
+func (s stopWith) Iterator() retry.Iterator {
+	return func() (time.Duration, error) { return retry.StopDelay, s.err }
+}
+
+func TestQuote(t *testing.T) {
+	t.Parallel()
+
+	if got, want := quote("a\"b\\c\nd"), `"a\"b\\c\nd"`; got != want {
+		t.Errorf("got: %s, want: %s", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package retrytest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gotidy/retry"
+)
+
+// NotifyCall is the arguments of a single retry.Notify call.
+type NotifyCall struct {
+	Err     error
+	Delay   time.Duration
+	Try     int
+	Elapsed time.Duration
+}
+
+// Notifications records retry.Notify calls. It is safe for concurrent use.
+//
+//	n := &retrytest.Notifications{}
+//	err := retry.Do(ctx, strategy, operation, retry.WithNotify(n.Notify))
+type Notifications struct {
+	mu    sync.Mutex
+	calls []NotifyCall
+}
+
+// Notify records the call, it matches retry.Notify.
+func (n *Notifications) Notify(err error, delay time.Duration, try int, elapsed time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.calls = append(n.calls, NotifyCall{Err: err, Delay: delay, Try: try, Elapsed: elapsed})
+}
+
+// Calls returns a copy of the recorded calls.
+func (n *Notifications) Calls() []NotifyCall {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]NotifyCall(nil), n.calls...)
+}
+
+// Delays returns the delays of the recorded calls.
+func (n *Notifications) Delays() []time.Duration {
+	calls := n.Calls()
+	delays := make([]time.Duration, len(calls))
+	for i, c := range calls {
+		delays[i] = c.Delay
+	}
+	return delays
+}
+
+// AssertNotify checks that notify was called with the specified delays and the tries were numbered from 1.
+func AssertNotify(t testing.TB, n *Notifications, delays ...time.Duration) {
+	t.Helper()
+
+	calls := n.Calls()
+	if len(calls) != len(delays) {
+		t.Errorf("notify calls want: %d, got: %d", len(delays), len(calls))
+		return
+	}
+	for i, c := range calls {
+		if c.Delay != delays[i] {
+			t.Errorf("notify call %d delay want: %s, got: %s", i+1, delays[i], c.Delay)
+		}
+		if c.Try != i+1 {
+			t.Errorf("notify call %d try want: %d, got: %d", i+1, i+1, c.Try)
+		}
+	}
+}
+
+func asError(t testing.TB, err error) *retry.Error {
+	t.Helper()
+
+	e := retry.As(err)
+	if e == nil {
+		t.Errorf("expected *retry.Error, got: %v", err)
+	}
+	return e
+}
+
+// AssertRetries checks that err is *retry.Error with the specified count of retries.
+func AssertRetries(t testing.TB, err error, retries int) {
+	t.Helper()
+
+	if e := asError(t, err); e != nil && e.Retries != retries {
+		t.Errorf("retries want: %d, got: %d", retries, e.Retries)
+	}
+}
+
+// AssertLastDelay checks that err is *retry.Error with the specified last delay.
+func AssertLastDelay(t testing.TB, err error, delay time.Duration) {
+	t.Helper()
+
+	if e := asError(t, err); e != nil && e.LastDelay != delay {
+		t.Errorf("last delay want: %s, got: %s", delay, e.LastDelay)
+	}
+}
+
+// AssertElapsedTime checks that err is *retry.Error with the specified elapsed time.
+// Use it with a fake clock, see retry.WithClock.
+func AssertElapsedTime(t testing.TB, err error, elapsed time.Duration) {
+	t.Helper()
+
+	if e := asError(t, err); e != nil && e.ElapsedTime != elapsed {
+		t.Errorf("elapsed time want: %s, got: %s", elapsed, e.ElapsedTime)
+	}
+}
@@ -0,0 +1,47 @@
+package retrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gotidy/retry"
+)
+
+type fakeT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...any) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssert(t *testing.T) {
+	t.Parallel()
+
+	clock := retry.NewAutoFakeClock(time.Now())
+	n := &Notifications{}
+	err := retry.Do(context.Background(), retry.Delays{time.Second, 2 * time.Second}, func(ctx context.Context) error {
+		return errors.New("fail")
+	}, retry.WithClock(clock), retry.WithNotify(n.Notify))
+
+	AssertNotify(t, n, time.Second, 2*time.Second)
+	AssertRetries(t, err, 3)
+	AssertLastDelay(t, err, 2*time.Second)
+	AssertElapsedTime(t, err, 3*time.Second)
+
+	ft := &fakeT{}
+	AssertNotify(ft, n, time.Second)
+	AssertRetries(ft, err, 1)
+	AssertLastDelay(ft, err, time.Second)
+	AssertElapsedTime(ft, err, time.Second)
+	AssertRetries(ft, errors.New("fail"), 1)
+	if len(ft.errors) != 5 {
+		t.Errorf("failed assertions want: %d, got: %d %v", 5, len(ft.errors), ft.errors)
+	}
+}
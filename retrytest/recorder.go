@@ -0,0 +1,49 @@
+// Package retrytest provides utilities for testing code that uses the retry package.
+package retrytest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/gotidy/retry"
+)
+
+// Recorder is a strategy that wraps another strategy and records every delay produced by its iterators.
+// It is safe for concurrent use.
+type Recorder struct {
+	Strategy retry.Strategy
+
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+// Record wraps the strategy with a recorder.
+func Record(strategy retry.Strategy) *Recorder {
+	return &Recorder{Strategy: strategy}
+}
+
+// Iterator returns an iterator that records the delays of the inherited iterator, including StopDelay.
+func (r *Recorder) Iterator() retry.Iterator {
+	iter := r.Strategy.Iterator()
+	return func() (time.Duration, error) {
+		d, err := iter()
+		r.mu.Lock()
+		r.delays = append(r.delays, d)
+		r.mu.Unlock()
+		return d, err
+	}
+}
+
+// Delays returns a copy of the recorded delays.
+func (r *Recorder) Delays() []time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]time.Duration(nil), r.delays...)
+}
+
+// Reset clears the recorded delays.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.delays = nil
+}
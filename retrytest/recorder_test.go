@@ -0,0 +1,29 @@
+package retrytest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gotidy/retry"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Parallel()
+
+	r := Record(retry.Delays{time.Second, 2 * time.Second})
+	next := r.Iterator()
+	for i := 0; i < 3; i++ {
+		_, _ = next()
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, retry.StopDelay}
+	if got := r.Delays(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got: %v, want: %v", got, want)
+	}
+
+	r.Reset()
+	if got := r.Delays(); len(got) != 0 {
+		t.Errorf("expected no delays, got: %v", got)
+	}
+}
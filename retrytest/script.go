@@ -0,0 +1,94 @@
+package retrytest
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/gotidy/retry"
+)
+
+// ErrScriptEnded is returned as a permanent error when the operation is called after all steps of the script are spent.
+var ErrScriptEnded = errors.New("script ended")
+
+// Step is a result of a single operation call.
+type Step[T any] struct {
+	Value T
+	Err   error
+}
+
+// Script is an operation builder that returns the specified sequence of results.
+// It is safe for concurrent use.
+type Script[T any] struct {
+	mu    sync.Mutex
+	steps []Step[T]
+	calls int
+}
+
+// NewScript creates an empty script.
+func NewScript[T any]() *Script[T] {
+	return &Script[T]{}
+}
+
+// Fail appends a step that returns the error.
+func (s *Script[T]) Fail(err error) *Script[T] {
+	return s.Step(Step[T]{Err: err})
+}
+
+// FailN appends n steps that return the error.
+func (s *Script[T]) FailN(n int, err error) *Script[T] {
+	for i := 0; i < n; i++ {
+		s.Fail(err)
+	}
+	return s
+}
+
+// Permanent appends a step that returns the error wrapped with retry.Permanent.
+func (s *Script[T]) Permanent(err error) *Script[T] {
+	return s.Fail(retry.Permanent(err))
+}
+
+// OK appends a step that returns the value.
+func (s *Script[T]) OK(v T) *Script[T] {
+	return s.Step(Step[T]{Value: v})
+}
+
+// Step appends the step.
+func (s *Script[T]) Step(step Step[T]) *Script[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = append(s.steps, step)
+	return s
+}
+
+// Operation returns an operation for retry.DoR that plays the script.
+func (s *Script[T]) Operation() func(ctx context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.calls >= len(s.steps) {
+			s.calls++
+			var zero T
+			return zero, retry.Permanent(ErrScriptEnded)
+		}
+		step := s.steps[s.calls]
+		s.calls++
+		return step.Value, step.Err
+	}
+}
+
+// Func returns an operation for retry.Do that plays the script.
+func (s *Script[T]) Func() func(ctx context.Context) error {
+	op := s.Operation()
+	return func(ctx context.Context) error {
+		_, err := op(ctx)
+		return err
+	}
+}
+
+// Calls returns the count of operation calls.
+func (s *Script[T]) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
@@ -0,0 +1,54 @@
+package retrytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gotidy/retry"
+)
+
+func TestScript(t *testing.T) {
+	t.Parallel()
+
+	errFail := errors.New("fail")
+	s := NewScript[int]().FailN(2, errFail).OK(10)
+
+	got, err := retry.DoR(context.Background(), retry.Zero(), s.Operation())
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if got != 10 {
+		t.Errorf("value want: %d, got: %d", 10, got)
+	}
+	if s.Calls() != 3 {
+		t.Errorf("calls want: %d, got: %d", 3, s.Calls())
+	}
+}
+
+func TestScript_Permanent(t *testing.T) {
+	t.Parallel()
+
+	errFail := errors.New("fail")
+	errPerm := errors.New("permanent")
+	s := NewScript[struct{}]().Fail(errFail).Fail(errFail).Permanent(errPerm).OK(struct{}{})
+
+	err := retry.Do(context.Background(), retry.Zero(), s.Func())
+	if !errors.Is(err, errPerm) {
+		t.Errorf("want: %s, got: %v", errPerm, err)
+	}
+	if s.Calls() != 3 {
+		t.Errorf("calls want: %d, got: %d", 3, s.Calls())
+	}
+}
+
+func TestScript_Ended(t *testing.T) {
+	t.Parallel()
+
+	s := NewScript[int]().Fail(errors.New("fail"))
+	_, err := retry.DoR(context.Background(), retry.Constant(time.Millisecond), s.Operation())
+	if !errors.Is(err, ErrScriptEnded) {
+		t.Errorf("want: %s, got: %v", ErrScriptEnded, err)
+	}
+}
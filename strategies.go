@@ -2,12 +2,17 @@ package retry
 
 import (
 	"errors"
+	"math"
 	"math/rand"
 	"time"
 )
 
-var ErrDelaysSpent = errors.New("all delays spent")
-var ErrStopped = errors.New("stopped")
+// Stop reasons of the strategies. A strategy can return its own error with StopDelay
+// to specify the reason of stopping, see Error.Reason.
+var (
+	ErrDelaysSpent = errors.New("all delays spent")
+	ErrStopped     = errors.New("stopped")
+)
 
 // StopDelay indicates that no more retries should be made.
 const StopDelay time.Duration = -1
@@ -61,6 +66,35 @@ func Stop() Constant {
 	return Constant(StopDelay)
 }
 
+// JitterMode is a mode of delay randomization.
+type JitterMode int
+
+const (
+	// JitterProportional randomizes delay in range [delay * (1 - Jitter), delay * (1 + Jitter)].
+	// It is the default mode.
+	JitterProportional JitterMode = iota
+	// JitterNone disables delay randomization.
+	JitterNone
+	// JitterFull randomizes delay in range [0, delay].
+	JitterFull
+	// JitterEqual randomizes delay in range [delay / 2, delay].
+	JitterEqual
+)
+
+func (m JitterMode) apply(delay time.Duration, factor, random float64) time.Duration {
+	switch m {
+	case JitterNone:
+		return delay
+	case JitterFull:
+		return time.Duration(random * float64(delay))
+	case JitterEqual:
+		half := delay / 2
+		return half + time.Duration(random*float64(delay-half))
+	default:
+		return jitter(delay, factor, random)
+	}
+}
+
 // ExponentialBackOff is exponential backoff strategy.
 type ExponentialBackOff struct {
 	// Start delay.
@@ -69,7 +103,9 @@ type ExponentialBackOff struct {
 	Factor float64
 	// Delay randomization. delay = delay * (random value in range [1 - Jitter, 1 + Jitter]).
 	Jitter float64
-	// Delay maximum.
+	// JitterMode is a mode of delay randomization, JitterProportional by default.
+	JitterMode JitterMode
+	// Delay maximum. The randomized delay never exceeds it.
 	MaxDelay time.Duration
 }
 
@@ -85,16 +121,127 @@ func TruncatedExponential(start time.Duration, factor, jitter float64, maxDelay
 
 // Iterator returns exponential backoff delays generator.
 func (e ExponentialBackOff) Iterator() Iterator {
-	rand := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
 	delay := e.Start
-	return func() (time.Duration, error) {
+	return randomized(func() time.Duration {
 		cur := delay
 		delay = time.Duration(float64(delay) * e.Factor)
 		if e.MaxDelay != 0 && delay >= e.MaxDelay {
 			delay = e.MaxDelay
 		}
-		return jitter(cur, e.Jitter, rand.Float64()), nil
+		return cur
+	}, e.JitterMode, e.Jitter, e.MaxDelay)
+}
+
+// LinearBackOff is linear backoff strategy.
+type LinearBackOff struct {
+	// Start delay.
+	Start time.Duration
+	// Step is added to the delay. Next delay = delay + step.
+	Step time.Duration
+	// Delay randomization, see ExponentialBackOff.Jitter.
+	Jitter float64
+	// JitterMode is a mode of delay randomization, JitterProportional by default.
+	JitterMode JitterMode
+	// Delay maximum. The randomized delay never exceeds it.
+	MaxDelay time.Duration
+}
+
+// Linear creates linear backoff strategy.
+func Linear(start, step time.Duration) LinearBackOff {
+	return LinearBackOff{Start: start, Step: step}
+}
+
+// Iterator returns linear backoff delays generator.
+func (l LinearBackOff) Iterator() Iterator {
+	delay := l.Start
+	return randomized(func() time.Duration {
+		cur := delay
+		delay = truncate(saturatedAdd(delay, l.Step), l.MaxDelay)
+		return cur
+	}, l.JitterMode, l.Jitter, l.MaxDelay)
+}
+
+// FibonacciBackOff is Fibonacci backoff strategy.
+// Delays are Start multiplied by Fibonacci numbers: Start, Start, 2 * Start, 3 * Start, 5 * Start...
+type FibonacciBackOff struct {
+	// Start delay.
+	Start time.Duration
+	// Delay randomization, see ExponentialBackOff.Jitter.
+	Jitter float64
+	// JitterMode is a mode of delay randomization, JitterProportional by default.
+	JitterMode JitterMode
+	// Delay maximum. The randomized delay never exceeds it.
+	MaxDelay time.Duration
+}
+
+// Fibonacci creates Fibonacci backoff strategy.
+func Fibonacci(start time.Duration) FibonacciBackOff {
+	return FibonacciBackOff{Start: start}
+}
+
+// Iterator returns Fibonacci backoff delays generator.
+func (f FibonacciBackOff) Iterator() Iterator {
+	prev, delay := time.Duration(0), f.Start
+	return randomized(func() time.Duration {
+		cur := delay
+		prev, delay = delay, truncate(saturatedAdd(prev, delay), f.MaxDelay)
+		return cur
+	}, f.JitterMode, f.Jitter, f.MaxDelay)
+}
+
+// PolynomialBackOff is polynomial backoff strategy.
+// Delay of the n-th retry = Start * n^Exponent.
+type PolynomialBackOff struct {
+	// Start delay.
+	Start time.Duration
+	// Exponent of the retry number.
+	Exponent float64
+	// Delay randomization, see ExponentialBackOff.Jitter.
+	Jitter float64
+	// JitterMode is a mode of delay randomization, JitterProportional by default.
+	JitterMode JitterMode
+	// Delay maximum. The randomized delay never exceeds it.
+	MaxDelay time.Duration
+}
+
+// Polynomial creates polynomial backoff strategy.
+func Polynomial(start time.Duration, exponent float64) PolynomialBackOff {
+	return PolynomialBackOff{Start: start, Exponent: exponent}
+}
+
+// Iterator returns polynomial backoff delays generator.
+func (p PolynomialBackOff) Iterator() Iterator {
+	n := 0
+	return randomized(func() time.Duration {
+		n++
+		delay := float64(p.Start) * math.Pow(float64(n), p.Exponent)
+		if delay >= math.MaxInt64 {
+			return truncate(math.MaxInt64, p.MaxDelay)
+		}
+		return truncate(time.Duration(delay), p.MaxDelay)
+	}, p.JitterMode, p.Jitter, p.MaxDelay)
+}
+
+// randomized returns the iterator over growing delays that are randomized and truncated.
+func randomized(next func() time.Duration, mode JitterMode, factor float64, maxDelay time.Duration) Iterator {
+	rand := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+	return func() (time.Duration, error) {
+		return truncate(mode.apply(next(), factor, rand.Float64()), maxDelay), nil
+	}
+}
+
+func saturatedAdd(a, b time.Duration) time.Duration {
+	if b > 0 && a > math.MaxInt64-b {
+		return math.MaxInt64
 	}
+	return a + b
+}
+
+func truncate(delay, maxDelay time.Duration) time.Duration {
+	if maxDelay != 0 && delay > maxDelay {
+		return maxDelay
+	}
+	return delay
 }
 
 func jitter(delay time.Duration, factor, random float64) time.Duration {
@@ -110,3 +257,40 @@ func jitter(delay time.Duration, factor, random float64) time.Duration {
 	// we want a 33% chance for selecting either 1, 2 or 3.
 	return time.Duration(minDelay + (random * (maxDelay - minDelay + 1)))
 }
+
+// DecorrelatedJitter is decorrelated jitter backoff strategy.
+// Next delay = min(Cap, random value in range [Base, previous delay * 3]).
+type DecorrelatedJitter struct {
+	// Base delay, it is also the minimum delay.
+	Base time.Duration
+	// Cap is the delay maximum, zero means unlimited.
+	Cap time.Duration
+}
+
+// Decorrelated creates decorrelated jitter backoff strategy.
+func Decorrelated(base, maxDelay time.Duration) DecorrelatedJitter {
+	return DecorrelatedJitter{Base: base, Cap: maxDelay}
+}
+
+// Iterator returns decorrelated jitter backoff delays generator.
+func (d DecorrelatedJitter) Iterator() Iterator {
+	rand := rand.New(rand.NewSource(time.Now().UnixNano())) //nolint:gosec
+	prev := d.Base
+	return func() (time.Duration, error) {
+		delay := truncate(decorrelated(d.Base, prev, rand.Float64()), d.Cap)
+		prev = delay
+		return delay, nil
+	}
+}
+
+func decorrelated(base, prev time.Duration, random float64) time.Duration {
+	upper := float64(prev) * 3
+	if upper < float64(base) {
+		return base
+	}
+	delay := float64(base) + random*(upper-float64(base))
+	if delay >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(delay)
+}
@@ -1,6 +1,9 @@
 package retry
 
 import (
+	"context"
+	"errors"
+	"math"
 	"reflect"
 	"testing"
 	"time"
@@ -107,3 +110,202 @@ func assertEquals[T comparable](t *testing.T, expected, actual T) {
 		t.Errorf("expected: %v, actual: %v", expected, actual)
 	}
 }
+
+func TestDecorrelatedJitter(t *testing.T) {
+	t.Parallel()
+
+	const base = 100 * time.Millisecond
+	const maxDelay = 10 * time.Second
+	const samples = 10000
+
+	next := Decorrelated(base, maxDelay).Iterator()
+	prev := base
+	var sum time.Duration
+	capped := 0
+	for i := 0; i < samples; i++ {
+		got, err := next()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		upper := 3 * prev
+		if upper > maxDelay {
+			upper = maxDelay
+		}
+		if got < base || got > upper {
+			t.Fatalf("expected between %v and %v, got %v", base, upper, got)
+		}
+		if got == maxDelay {
+			capped++
+		}
+		sum += got
+		prev = got
+	}
+
+	// The delays grow quickly to the cap, so most of them are close to it.
+	if mean := sum / samples; mean < maxDelay/4 || mean > maxDelay {
+		t.Errorf("unexpected mean delay: %v", mean)
+	}
+	if capped == 0 {
+		t.Error("expected some delays to be capped")
+	}
+}
+
+func TestDecorrelatedJitter_Uncapped(t *testing.T) {
+	t.Parallel()
+
+	next := Decorrelated(time.Second, 0).Iterator()
+	for i := 0; i < 100; i++ {
+		if got, _ := next(); got < time.Second {
+			t.Fatalf("expected at least %v, got %v", time.Second, got)
+		}
+	}
+}
+
+func TestDecorrelated(t *testing.T) {
+	t.Parallel()
+
+	assertEquals(t, 1*time.Second, decorrelated(time.Second, time.Second, 0))
+	assertEquals(t, 3*time.Second, decorrelated(time.Second, time.Second, 1))
+	assertEquals(t, 2*time.Second, decorrelated(time.Second, time.Second, 0.5))
+	assertEquals(t, time.Duration(math.MaxInt64), decorrelated(time.Second, math.MaxInt64, 1))
+}
+
+func TestDecorrelatedJitter_Wrappers(t *testing.T) {
+	t.Parallel()
+
+	count := 0
+	err := Do(context.Background(), MaxRetries(3, MaxElapsedTime(time.Hour, Decorrelated(time.Millisecond, 2*time.Millisecond))), func(ctx context.Context) error {
+		count++
+		return errors.New("error")
+	})
+	if err == nil {
+		t.Error("expected error but got nil")
+	}
+	if count != 4 {
+		t.Errorf("unexpected count of retries: %d, expected: %d", count, 4)
+	}
+}
+
+func TestExponentialBackOff_JitterMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		mode     JitterMode
+		minRatio float64
+		maxRatio float64
+	}{
+		{name: "None", mode: JitterNone, minRatio: 1, maxRatio: 1},
+		{name: "Proportional", mode: JitterProportional, minRatio: 0.5, maxRatio: 1.5},
+		{name: "Full", mode: JitterFull, minRatio: 0, maxRatio: 1},
+		{name: "Equal", mode: JitterEqual, minRatio: 0.5, maxRatio: 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exp := ExponentialBackOff{Start: time.Second, Factor: 2, Jitter: 0.5, JitterMode: tt.mode}
+			delay := exp.Start
+			next := exp.Iterator()
+			for i := 0; i < 10; i++ {
+				got, _ := next()
+				minDelay := time.Duration(tt.minRatio * float64(delay))
+				maxDelay := time.Duration(tt.maxRatio*float64(delay)) + 1
+				if got < minDelay || got > maxDelay {
+					t.Errorf("expected between %v and %v, got %v", minDelay, maxDelay, got)
+				}
+				delay *= 2
+			}
+		})
+	}
+}
+
+func TestExponentialBackOff_JitterMaxDelay(t *testing.T) {
+	t.Parallel()
+
+	for _, mode := range []JitterMode{JitterNone, JitterProportional, JitterFull, JitterEqual} {
+		exp := ExponentialBackOff{Start: time.Second, Factor: 2, Jitter: 0.9, JitterMode: mode, MaxDelay: 5 * time.Second}
+		next := exp.Iterator()
+		for i := 0; i < 100; i++ {
+			if got, _ := next(); got > exp.MaxDelay {
+				t.Fatalf("mode %d: expected at most %v, got %v", mode, exp.MaxDelay, got)
+			}
+		}
+	}
+}
+
+func testGrowth(t *testing.T, s Strategy, want []time.Duration) {
+	t.Helper()
+
+	next := s.Iterator()
+	for i, w := range want {
+		got, err := next()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if got != w {
+			t.Errorf("delay %d got: %v, want: %v", i, got, w)
+		}
+	}
+}
+
+func TestLinear(t *testing.T) {
+	t.Parallel()
+
+	s := Linear(time.Second, 2*time.Second)
+	testGrowth(t, s, []time.Duration{1 * time.Second, 3 * time.Second, 5 * time.Second, 7 * time.Second})
+
+	s.MaxDelay = 4 * time.Second
+	testGrowth(t, s, []time.Duration{1 * time.Second, 3 * time.Second, 4 * time.Second, 4 * time.Second})
+}
+
+func TestFibonacci(t *testing.T) {
+	t.Parallel()
+
+	s := Fibonacci(time.Second)
+	testGrowth(t, s, []time.Duration{1 * time.Second, 1 * time.Second, 2 * time.Second, 3 * time.Second, 5 * time.Second, 8 * time.Second})
+
+	s.MaxDelay = 4 * time.Second
+	testGrowth(t, s, []time.Duration{1 * time.Second, 1 * time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 4 * time.Second})
+
+	// Doesn't overflow.
+	next := Fibonacci(time.Second).Iterator()
+	for i := 0; i < 200; i++ {
+		if got, _ := next(); got < 0 {
+			t.Fatalf("negative delay: %v", got)
+		}
+	}
+}
+
+func TestPolynomial(t *testing.T) {
+	t.Parallel()
+
+	s := Polynomial(time.Second, 2)
+	testGrowth(t, s, []time.Duration{1 * time.Second, 4 * time.Second, 9 * time.Second, 16 * time.Second})
+
+	s.MaxDelay = 5 * time.Second
+	testGrowth(t, s, []time.Duration{1 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second})
+}
+
+func TestGrowth_Jitter(t *testing.T) {
+	t.Parallel()
+
+	strategies := []Strategy{
+		LinearBackOff{Start: time.Second, Step: time.Second, Jitter: 0.5, JitterMode: JitterFull, MaxDelay: 3 * time.Second},
+		FibonacciBackOff{Start: time.Second, Jitter: 0.5, MaxDelay: 3 * time.Second},
+		PolynomialBackOff{Start: time.Second, Exponent: 1.5, Jitter: 0.5, JitterMode: JitterEqual, MaxDelay: 3 * time.Second},
+	}
+	for _, s := range strategies {
+		next := MaxRetries(20, s).Iterator()
+		for {
+			got, _ := next()
+			if got == StopDelay {
+				break
+			}
+			if got < 0 || got > 3*time.Second {
+				t.Fatalf("%T: expected between 0 and %v, got %v", s, 3*time.Second, got)
+			}
+		}
+	}
+}
@@ -1,10 +1,18 @@
 package retry
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"time"
 )
 
+// Stop reasons of the wrappers, the stop errors wrap them.
+var (
+	ErrMaxRetries     = errors.New("maximum retries elapsed")
+	ErrMaxElapsedTime = errors.New("retrying time elapsed")
+)
+
 // Wrapper of strategy.
 type Wrapper interface {
 	Wrap(s Strategy) Strategy
@@ -40,7 +48,7 @@ func (w MaxRetriesWrapper) Iterator() Iterator {
 	iter := w.Strategy.Iterator()
 	return func() (time.Duration, error) {
 		if n >= w.MaxRetries {
-			return StopDelay, fmt.Errorf("maximum retries elapsed: %d", w.MaxRetries)
+			return StopDelay, fmt.Errorf("%w: %d", ErrMaxRetries, w.MaxRetries)
 		}
 		n++
 		return iter()
@@ -51,6 +59,8 @@ func (w MaxRetriesWrapper) Iterator() Iterator {
 type MaxElapsedTimeWrapper struct {
 	MaxElapsedTime time.Duration
 	Strategy       Strategy
+	// Clock is a time source, SystemClock by default.
+	Clock Clock
 }
 
 // MaxElapsedTime wraps the strategy with the max elapsed time stopper.
@@ -63,17 +73,113 @@ func (w MaxElapsedTimeWrapper) Wrap(s Strategy) Strategy {
 	return MaxElapsedTimeWrapper{
 		MaxElapsedTime: w.MaxElapsedTime,
 		Strategy:       s,
+		Clock:          w.Clock,
 	}
 }
 
 // Iterator returns an iterator that iterate over the inherited iterator and stops when the time be elapsed.
 func (w MaxElapsedTimeWrapper) Iterator() Iterator {
-	start := time.Now()
+	clock := clockOrDefault(w.Clock)
+	start := clock.Now()
 	iter := w.Strategy.Iterator()
 	return func() (time.Duration, error) {
-		if time.Since(start) > w.MaxElapsedTime {
-			return StopDelay, fmt.Errorf("retrying time elapsed: %s", w.MaxElapsedTime.String())
+		if clock.Now().Sub(start) > w.MaxElapsedTime {
+			return StopDelay, fmt.Errorf("%w: %s", ErrMaxElapsedTime, w.MaxElapsedTime.String())
 		}
 		return iter()
 	}
 }
+
+// CapWrapper wraps the strategy with the delay maximum.
+type CapWrapper struct {
+	// MaxDelay is the delay maximum, zero means no limit.
+	MaxDelay time.Duration
+	Strategy Strategy
+}
+
+// Cap wraps the strategy with the delay maximum.
+func Cap(d time.Duration, strategy Strategy) CapWrapper {
+	return CapWrapper{MaxDelay: d, Strategy: strategy}
+}
+
+// Wrap other Strategy.
+func (w CapWrapper) Wrap(s Strategy) Strategy {
+	return CapWrapper{
+		MaxDelay: w.MaxDelay,
+		Strategy: s,
+	}
+}
+
+// Iterator returns an iterator that iterate over the inherited iterator and limits the delays by the maximum.
+func (w CapWrapper) Iterator() Iterator {
+	return mapDelays(w.Strategy.Iterator(), func(d time.Duration) time.Duration {
+		return truncate(d, w.MaxDelay)
+	})
+}
+
+// FloorWrapper wraps the strategy with the delay minimum.
+type FloorWrapper struct {
+	MinDelay time.Duration
+	Strategy Strategy
+}
+
+// Floor wraps the strategy with the delay minimum.
+func Floor(d time.Duration, strategy Strategy) FloorWrapper {
+	return FloorWrapper{MinDelay: d, Strategy: strategy}
+}
+
+// Wrap other Strategy.
+func (w FloorWrapper) Wrap(s Strategy) Strategy {
+	return FloorWrapper{
+		MinDelay: w.MinDelay,
+		Strategy: s,
+	}
+}
+
+// Iterator returns an iterator that iterate over the inherited iterator and limits the delays by the minimum.
+func (w FloorWrapper) Iterator() Iterator {
+	return mapDelays(w.Strategy.Iterator(), func(d time.Duration) time.Duration {
+		return max(d, w.MinDelay)
+	})
+}
+
+// ScaleWrapper wraps the strategy with the delay multiplier.
+type ScaleWrapper struct {
+	Factor   float64
+	Strategy Strategy
+}
+
+// Scale wraps the strategy with the delay multiplier.
+func Scale(factor float64, strategy Strategy) ScaleWrapper {
+	return ScaleWrapper{Factor: factor, Strategy: strategy}
+}
+
+// Wrap other Strategy.
+func (w ScaleWrapper) Wrap(s Strategy) Strategy {
+	return ScaleWrapper{
+		Factor:   w.Factor,
+		Strategy: s,
+	}
+}
+
+// Iterator returns an iterator that iterate over the inherited iterator and multiplies the delays by the factor.
+func (w ScaleWrapper) Iterator() Iterator {
+	return mapDelays(w.Strategy.Iterator(), func(d time.Duration) time.Duration {
+		scaled := float64(d) * w.Factor
+		if scaled >= math.MaxInt64 {
+			return math.MaxInt64
+		}
+		return max(time.Duration(scaled), 0)
+	})
+}
+
+// mapDelays returns an iterator that maps the delays of the iterator, StopDelay is kept as is.
+func mapDelays(iter Iterator, f func(d time.Duration) time.Duration) Iterator {
+	return func() (time.Duration, error) {
+		d, err := iter()
+		if d == StopDelay {
+			return d, err
+		}
+		return f(d), err
+	}
+}
@@ -1,6 +1,8 @@
 package retry
 
 import (
+	"math"
+	"reflect"
 	"testing"
 	"time"
 )
@@ -38,3 +40,45 @@ func TestMaxElapsedTime(t *testing.T) {
 		t.Errorf("expected %s >= %s", d, maxElapsedTime)
 	}
 }
+
+func TestMaxElapsedTime_Clock(t *testing.T) {
+	t.Parallel()
+
+	clock := NewFakeClock(time.Now())
+	iter := MaxElapsedTimeWrapper{MaxElapsedTime: time.Hour, Strategy: Zero(), Clock: clock}.Iterator()
+	if d, err := iter(); d == StopDelay || err != nil {
+		t.Fatalf("unexpected stop: %v", err)
+	}
+	clock.Advance(time.Hour + time.Nanosecond)
+	if d, err := iter(); d != StopDelay || err == nil {
+		t.Errorf("expected stop, got: %s", d)
+	}
+}
+
+func TestCapFloorScale(t *testing.T) {
+	t.Parallel()
+
+	delays := Delays{time.Second, 5 * time.Second, 10 * time.Second}
+	tests := []struct {
+		name     string
+		strategy Strategy
+		want     []time.Duration
+	}{
+		{name: "Cap", strategy: Cap(4*time.Second, delays), want: []time.Duration{time.Second, 4 * time.Second, 4 * time.Second, StopDelay}},
+		{name: "Floor", strategy: Floor(6*time.Second, delays), want: []time.Duration{6 * time.Second, 6 * time.Second, 10 * time.Second, StopDelay}},
+		{name: "Scale", strategy: Scale(1.5, delays), want: []time.Duration{1500 * time.Millisecond, 7500 * time.Millisecond, 15 * time.Second, StopDelay}},
+		{name: "Wrap", strategy: CapWrapper{MaxDelay: 2 * time.Second}.Wrap(FloorWrapper{MinDelay: 3 * time.Second}.Wrap(ScaleWrapper{Factor: 0.5}.Wrap(delays))), want: []time.Duration{2 * time.Second, 2 * time.Second, 2 * time.Second, StopDelay}},
+		{name: "Cap zero", strategy: Cap(0, delays), want: []time.Duration{time.Second, 5 * time.Second, 10 * time.Second, StopDelay}},
+		{name: "Overflow", strategy: Scale(2, Constant(math.MaxInt64)), want: []time.Duration{math.MaxInt64}},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got, _ := iterate(tt.strategy, len(tt.want), nil); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got: %v, want: %v", got, tt.want)
+			}
+		})
+	}
+}